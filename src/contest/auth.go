@@ -0,0 +1,227 @@
+package contest
+
+import (
+	"context"
+	"contract-testing/src/serialization"
+	"contract-testing/src/serialization/openapi"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// oauth2Token is an access token from an OAuth2 token endpoint.
+type oauth2Token struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+
+	expiry time.Time
+}
+
+// tokenExpiryMargin is the time before the expiry of a token at which it is refreshed.
+const tokenExpiryMargin = 10 * time.Second
+
+func (t oauth2Token) expired() bool {
+	return !t.expiry.IsZero() && time.Now().Add(tokenExpiryMargin).After(t.expiry)
+}
+
+var (
+	oauth2Tokens      = make(map[string]*oauth2Token)
+	oauth2TokenLocks  = make(map[string]*sync.Mutex)
+	oauth2TokensMutex sync.Mutex
+)
+
+// oauth2TokenLock returns the lock held while the token cached with the key is fetched, so tokens of other credentials
+// can be fetched at the same time.
+func oauth2TokenLock(key string) *sync.Mutex {
+	oauth2TokensMutex.Lock()
+	defer oauth2TokensMutex.Unlock()
+
+	lock, found := oauth2TokenLocks[key]
+	if !found {
+		lock = &sync.Mutex{}
+		oauth2TokenLocks[key] = lock
+	}
+	return lock
+}
+
+// contractAuths returns the credentials used for the contract. Credentials of the contract replace the credentials of
+// the suite.
+func contractAuths(contract serialization.Contract, suite serialization.Suite) serialization.Auths {
+	if len(contract.Auth) > 0 {
+		if contract.Auth.Disabled() {
+			return nil
+		}
+		return contract.Auth
+	}
+	return suite.Auth
+}
+
+// stripCredentials removes the headers and query parameters with the given names from a request, so only the
+// credentials added by applyAuth are sent. Header names are case-insensitive. The URL without the query parameters is
+// returned.
+func stripCredentials(rawUrl string, names []string, headers map[string]string, query map[string]string) string {
+	if len(names) == 0 {
+		return rawUrl
+	}
+
+	for _, name := range names {
+		for key := range headers {
+			if strings.EqualFold(key, name) {
+				delete(headers, key)
+			}
+		}
+		delete(query, name)
+	}
+
+	u, err := url.Parse(rawUrl)
+	if err != nil || u.RawQuery == "" {
+		return rawUrl
+	}
+	values := u.Query()
+	stripped := false
+	for _, name := range names {
+		if _, found := values[name]; found {
+			values.Del(name)
+			stripped = true
+		}
+	}
+	if !stripped {
+		return rawUrl
+	}
+	u.RawQuery = values.Encode()
+	return u.String()
+}
+
+// applyAuth adds the credentials to the headers or query parameters of a request.
+func applyAuth(ctx context.Context, client *http.Client, auths serialization.Auths, headers map[string]string, query map[string]string) error {
+	for _, auth := range auths {
+		switch auth.Type {
+		case serialization.AuthTypeBasic:
+			credentials := base64.StdEncoding.EncodeToString([]byte(auth.Username + ":" + auth.Password))
+			headers["Authorization"] = "Basic " + credentials
+		case serialization.AuthTypeBearer:
+			headers["Authorization"] = "Bearer " + auth.Token
+		case serialization.AuthTypeApiKey:
+			switch auth.In {
+			case openapi.ParameterInHeader, "":
+				headers[auth.Name] = auth.Value
+			case openapi.ParameterInQuery:
+				query[auth.Name] = auth.Value
+			case openapi.ParameterInCookie:
+				cookie := (&http.Cookie{Name: auth.Name, Value: auth.Value}).String()
+				if headers["Cookie"] != "" {
+					cookie = headers["Cookie"] + "; " + cookie
+				}
+				headers["Cookie"] = cookie
+			default:
+				return fmt.Errorf("unsupported API key location %s", auth.In)
+			}
+		case serialization.AuthTypeOAuth2:
+			token, err := fetchOAuth2Token(ctx, client, auth)
+			if err != nil {
+				return err
+			}
+			headers["Authorization"] = "Bearer " + token
+		case serialization.AuthTypeNone:
+		default:
+			return fmt.Errorf("unknown auth type %s", auth.Type)
+		}
+	}
+	return nil
+}
+
+// fetchOAuth2Token returns an access token for the credentials. Tokens are cached until they expire and then refreshed
+// using the refresh token, if there is one, or fetched again.
+func fetchOAuth2Token(ctx context.Context, client *http.Client, auth serialization.Auth) (string, error) {
+	key := strings.Join([]string{auth.TokenUrl, auth.Grant, auth.ClientId, auth.Username, strings.Join(auth.Scopes, " ")}, "|")
+
+	lock := oauth2TokenLock(key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	oauth2TokensMutex.Lock()
+	cached, found := oauth2Tokens[key]
+	oauth2TokensMutex.Unlock()
+	if found && !cached.expired() {
+		return cached.AccessToken, nil
+	}
+
+	var token *oauth2Token
+	var err error
+	if found && cached.RefreshToken != "" {
+		token, err = requestOAuth2Token(ctx, client, auth, url.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {cached.RefreshToken},
+		})
+	}
+	if token == nil {
+		values := url.Values{"grant_type": {auth.Grant}}
+		if auth.Grant == "" {
+			values.Set("grant_type", serialization.OAuth2GrantClientCredentials)
+		}
+		if auth.Grant == serialization.OAuth2GrantPassword {
+			values.Set("username", auth.Username)
+			values.Set("password", auth.Password)
+		}
+		if len(auth.Scopes) > 0 {
+			values.Set("scope", strings.Join(auth.Scopes, " "))
+		}
+		token, err = requestOAuth2Token(ctx, client, auth, values)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	oauth2TokensMutex.Lock()
+	oauth2Tokens[key] = token
+	oauth2TokensMutex.Unlock()
+	return token.AccessToken, nil
+}
+
+func requestOAuth2Token(ctx context.Context, client *http.Client, auth serialization.Auth, values url.Values) (*oauth2Token, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, auth.TokenUrl, strings.NewReader(values.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if auth.ClientId != "" {
+		req.SetBasicAuth(url.QueryEscape(auth.ClientId), url.QueryEscape(auth.ClientSecret))
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token request to %s failed with status %d", auth.TokenUrl, res.StatusCode)
+	}
+
+	token := &oauth2Token{}
+	if err = json.Unmarshal(body, token); err != nil {
+		return nil, fmt.Errorf("invalid token response from %s: %s", auth.TokenUrl, err)
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("no access token in response from %s", auth.TokenUrl)
+	}
+	if token.ExpiresIn > 0 {
+		token.expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
@@ -0,0 +1,79 @@
+package contest
+
+import (
+	"context"
+	"contract-testing/src/serialization"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSecurityContractStripsCredentials(t *testing.T) {
+	var received *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	suite := serialization.Suite{
+		Headers: map[string]string{
+			"authorization": "Bearer suite",
+			"Cookie":        "session=suite",
+			"X-Api-Key":     "suite",
+			"X-Request-Id":  "1",
+		},
+	}
+	contract := serialization.Contract{
+		Url:              server.URL + "/pets?api_key=suite&limit=1",
+		Method:           http.MethodGet,
+		Name:             "listPets[security:none]",
+		Parameters:       map[string]string{"query:api_key": "parameter"},
+		Auth:             serialization.Auths{{Type: serialization.AuthTypeNone}},
+		Expect:           serialization.Expect{Status: http.StatusUnauthorized},
+		StripCredentials: []string{"Authorization", "Cookie", "X-Api-Key", "api_key"},
+	}
+
+	cr := RunContract(context.Background(), contract, suite, NewSeverity(suite, contract))
+	if received == nil {
+		t.Fatalf("no request received: %v", cr.Failures)
+	}
+	for _, name := range []string{"Authorization", "Cookie", "X-Api-Key"} {
+		if value := received.Header.Get(name); value != "" {
+			t.Errorf("header %s was sent: %s", name, value)
+		}
+	}
+	if value := received.URL.Query().Get("api_key"); value != "" {
+		t.Errorf("query parameter api_key was sent: %s", value)
+	}
+	if received.Header.Get("X-Request-Id") != "1" || received.URL.Query().Get("limit") != "1" {
+		t.Errorf("other headers and query parameters were not sent: %s", received.URL)
+	}
+}
+
+func TestSecurityContractSendsInvalidCredentials(t *testing.T) {
+	var received *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	suite := serialization.Suite{Headers: map[string]string{"X-Api-Key": "suite"}}
+	contract := serialization.Contract{
+		Url:              server.URL + "/pets",
+		Method:           http.MethodGet,
+		Name:             "listPets[security:invalid]",
+		Auth:             serialization.Auths{{Type: serialization.AuthTypeApiKey, In: "header", Name: "X-Api-Key", Value: "invalid"}},
+		Expect:           serialization.Expect{Status: http.StatusUnauthorized},
+		StripCredentials: []string{"Authorization", "Cookie", "X-Api-Key"},
+	}
+
+	RunContract(context.Background(), contract, suite, NewSeverity(suite, contract))
+	if received == nil {
+		t.Fatal("no request received")
+	}
+	if values := received.Header.Values("X-Api-Key"); len(values) != 1 || values[0] != "invalid" {
+		t.Errorf("expected only the invalid API key, got %v", values)
+	}
+}
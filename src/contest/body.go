@@ -0,0 +1,181 @@
+package contest
+
+import (
+	"bytes"
+	"contract-testing/src/serialization"
+	"contract-testing/src/validation"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"sort"
+)
+
+// EncodeBody encodes the body of a contract according to its BodyType. It returns the encoded body and the content
+// type of the encoding. A nil body is returned if the contract has no body.
+func EncodeBody(contract serialization.Contract) ([]byte, string, error) {
+	if contract.Body == nil {
+		return nil, "", nil
+	}
+
+	switch contract.BodyType {
+	case "", serialization.BodyTypeJson:
+		body, err := validation.JsonMarshal(contract.Body)
+		return body, "application/json", err
+	case serialization.BodyTypeForm:
+		return encodeFormBody(contract.Body)
+	case serialization.BodyTypeMultipart:
+		return encodeMultipartBody(contract.Body)
+	case serialization.BodyTypeRaw:
+		if path, isFile := bodyFile(contract.Body); isFile {
+			return readBodyFile(path)
+		}
+		switch contract.Body.(type) {
+		case map[string]interface{}, map[interface{}]interface{}, []interface{}:
+			return nil, "", fmt.Errorf("a raw body must be a string or {file: path}")
+		}
+		return []byte(fmt.Sprint(contract.Body)), "text/plain; charset=utf-8", nil
+	case serialization.BodyTypeFile:
+		return readBodyFile(fmt.Sprint(contract.Body))
+	}
+	return nil, "", fmt.Errorf("unknown body type %s", contract.BodyType)
+}
+
+// bodyFields returns the fields of a form body sorted by their name.
+func bodyFields(body interface{}) (map[string]interface{}, []string, error) {
+	retyped, err := validation.RetypeKeysToStrings(body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fields, ok := retyped.(map[string]interface{})
+	if !ok {
+		return nil, nil, fmt.Errorf("the body of a form must be a map")
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fields, names, nil
+}
+
+// bodyFile checks if the value is a map of the form {file: path} and returns the path.
+func bodyFile(value interface{}) (string, bool) {
+	retyped, err := validation.RetypeKeysToStrings(value)
+	if err != nil {
+		return "", false
+	}
+	m, ok := retyped.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	path, ok := m["file"].(string)
+	return path, ok
+}
+
+// fieldValues converts a field value into its string values. Arrays result in multiple values.
+func fieldValues(value interface{}) []string {
+	if values, ok := value.([]interface{}); ok {
+		converted := make([]string, len(values))
+		for i, v := range values {
+			converted[i] = fmt.Sprint(v)
+		}
+		return converted
+	}
+	return []string{fmt.Sprint(value)}
+}
+
+func encodeFormBody(body interface{}) ([]byte, string, error) {
+	fields, names, err := bodyFields(body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	values := url.Values{}
+	for _, name := range names {
+		for _, value := range fieldValues(fields[name]) {
+			values.Add(name, value)
+		}
+	}
+	return []byte(values.Encode()), "application/x-www-form-urlencoded", nil
+}
+
+// encodeMultipartBody encodes the body as multipart/form-data. A field of the form {file: path} uploads the file at
+// path, the optional keys filename and contentType override the name and content type of the uploaded file.
+func encodeMultipartBody(body interface{}) ([]byte, string, error) {
+	fields, names, err := bodyFields(body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	buffer := &bytes.Buffer{}
+	writer := multipart.NewWriter(buffer)
+	for _, name := range names {
+		if path, isFile := bodyFile(fields[name]); isFile {
+			file := fields[name].(map[string]interface{})
+			if err = writeMultipartFile(writer, name, path, file); err != nil {
+				return nil, "", err
+			}
+			continue
+		}
+
+		for _, value := range fieldValues(fields[name]) {
+			if err = writer.WriteField(name, value); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	if err = writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return buffer.Bytes(), writer.FormDataContentType(), nil
+}
+
+func writeMultipartFile(writer *multipart.Writer, name string, path string, file map[string]interface{}) error {
+	content, contentType, err := readBodyFile(path)
+	if err != nil {
+		return err
+	}
+
+	filename := filepath.Base(path)
+	if value, ok := file["filename"].(string); ok {
+		filename = value
+	}
+	if value, ok := file["contentType"].(string); ok {
+		contentType = value
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{
+		"name":     name,
+		"filename": filename,
+	}))
+	header.Set("Content-Type", contentType)
+
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(content)
+	return err
+}
+
+// readBodyFile reads a file that is sent in a request and returns its content and its content type, which is derived
+// from the file extension.
+func readBodyFile(path string) ([]byte, string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(path))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return content, contentType, nil
+}
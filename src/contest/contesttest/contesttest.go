@@ -0,0 +1,63 @@
+// Package contesttest runs contest suites against test servers as part of go test.
+package contesttest
+
+import (
+	"context"
+	"contract-testing/src/contest"
+	"contract-testing/src/serialization"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Run runs the contracts of the suite against the server and reports every contract as a subtest named after the
+// contract, or after its URL without the URL of the server if it has no name, so the names don't change with the port of
+// the server.
+// Failures fail the subtest, warnings are only logged. The URLs of all contracts are rebased onto the URL of the server.
+func Run(t *testing.T, suite serialization.Suite, server *httptest.Server) *contest.Report {
+	t.Helper()
+
+	if server.TLS != nil {
+		// The certificate of the test server is self-signed
+		insecure := true
+		suite.Http.InsecureSkipVerify = &insecure
+	}
+
+	options := contest.Options{
+		BaseUrl: server.URL,
+		OnResult: func(res contest.ContractResult) {
+			name := res.Contract
+			if name == "" {
+				name = strings.Replace(res.Name, server.URL, "", 1)
+			}
+			t.Run(name, func(t *testing.T) {
+				if res.Skipped {
+					t.Skip("the run was cancelled before the contract has run to completion")
+				}
+				report := t.Logf
+				if res.Verdict >= contest.ContractFail {
+					report = t.Errorf
+				}
+				for _, failure := range res.Failures {
+					report("%s", failure)
+				}
+			})
+		},
+	}
+	report, err := contest.Run(context.Background(), suite, options)
+	if err != nil {
+		t.Fatalf("could not run suite: %s", err)
+	}
+	return report
+}
+
+// RunFile loads the suite from a contest YAML file and runs it with Run.
+func RunFile(t *testing.T, path string, server *httptest.Server) *contest.Report {
+	t.Helper()
+
+	suite, err := serialization.LoadSuite(path)
+	if err != nil {
+		t.Fatalf("could not load suite %s: %s", path, err)
+	}
+	return Run(t, *suite, server)
+}
@@ -0,0 +1,750 @@
+package contest
+
+import (
+	"context"
+	"contract-testing/src/serialization"
+	"contract-testing/src/serialization/openapi"
+	"contract-testing/src/validation"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+type FailureReason string
+
+const (
+	FailureDebug        FailureReason = "debug"                   // Debug output
+	FailureContract     FailureReason = "contract"                // An invalid contract
+	FailureHttp         FailureReason = "http"                    // An error while running the HTTP request
+	FailureIO           FailureReason = "io"                      // An error while fetching the data
+	FailureHttpStatus   FailureReason = "unexpected.status"       // An unexpected HTTP status code
+	FailureFormat       FailureReason = "format"                  // An invalid response format
+	FailureSchema       FailureReason = "unexpected.schema"       // An invalid response Schema
+	FailureContentType  FailureReason = "unexpected.content-type" // An unexpected content type
+	FailureResponseTime FailureReason = "unexpected.responseTime" // The response time was longer than expected
+	FailureBody         FailureReason = "unexpected.body"         // A value in the response body was not as expected
+	FailureAuth         FailureReason = "auth"                    // The credentials could not be obtained
+	FailureSnapshot     FailureReason = "unexpected.snapshot"     // The response body differs from the snapshot
+)
+
+type Failure struct {
+	Reason  FailureReason
+	Comment string
+	// Messages are the individual messages of a failure with several of them, e.g. of a schema mismatch. The comment
+	// joins them.
+	Messages []string
+	// Ticket is the ticket of the known failure matching the failure, if any. The tickets of the known failures
+	// matching the messages of a failure are joined by commas.
+	Ticket string
+	// Occurrences is the number of runs of a load test the failure occurred in, Runs the number of runs. Both are 0
+	// outside of load tests.
+	Occurrences int
+	Runs        int
+
+	// knownExpiry marks the failure reporting an expired known failure, which no known failure can match
+	knownExpiry bool
+}
+
+func (f Failure) String() string {
+	text := string(f.Reason)
+	if f.Comment != "" {
+		text += ": " + f.Comment
+	}
+	if f.Runs > 0 {
+		if f.Comment == "" {
+			text += ":"
+		}
+		text += fmt.Sprintf(" in %d of %d runs", f.Occurrences, f.Runs)
+	}
+	if f.Ticket != "" {
+		text += " (known: " + f.Ticket + ")"
+	}
+	return text
+}
+
+type ContractResult struct {
+	Name string
+	// Contract is the name of the contract, which unlike Name doesn't contain its URL. It is empty for unnamed contracts
+	Contract string
+	Failures []Failure
+
+	// StatusCode is the status code of the response or 0 if there was none
+	StatusCode int
+	// ResponseTime is the time in ms until the response was received
+	ResponseTime int64
+	// Attempts is the number of times the contract was run
+	Attempts int
+	// FailedAttempts contains the failures of every attempt before the final one
+	FailedAttempts [][]Failure
+	// Verdict is set by RunContracts according to the severity of the suite
+	Verdict ContractVerdict
+	// Skipped is true if the run was cancelled before the contract has run to completion
+	Skipped bool
+	// Load summarizes the runs of the contract in load mode, it is nil otherwise
+	Load *LoadStats
+	// Exchange is the last request sent for the contract and its response, it is nil if no request was sent
+	Exchange *Exchange
+	// Tags are the tags of the contract
+	Tags []string
+
+	// body is the body of the response, which the retry condition until is checked against
+	body []byte
+	// redact redacts the secrets of the request and its response from the failures
+	redact redactor
+	// key identifies the contract across runs, see contractKey
+	key string
+}
+
+type ContractVerdict int
+
+const (
+	ContractPass  ContractVerdict = 0b00000
+	ContractInfo  ContractVerdict = 0b00001
+	ContractWarn  ContractVerdict = 0b00010
+	ContractXFail ContractVerdict = 0b00100 // Only known failures
+	ContractXPass ContractVerdict = 0b01000 // A known failure of the contract did not occur
+	ContractFail  ContractVerdict = 0b10000
+)
+
+func NewContractResult(name string) ContractResult {
+	return ContractResult{
+		Name:     name,
+		Failures: make([]Failure, 0),
+		Attempts: 1,
+	}
+}
+
+func (c *ContractResult) failure(reason FailureReason, comment string) {
+	if reason == "" {
+		return
+	}
+	c.Failures = append(c.Failures, Failure{
+		Reason:  reason,
+		Comment: comment,
+	})
+}
+
+// failureWithMessages adds a failure with several messages, e.g. the messages of CheckSchema.
+func (c *ContractResult) failureWithMessages(reason FailureReason, messages []string) {
+	if reason == "" {
+		return
+	}
+	c.Failures = append(c.Failures, newFailureWithMessages(reason, messages))
+}
+
+func newFailureWithMessages(reason FailureReason, messages []string) Failure {
+	failure := Failure{Reason: reason, Comment: strings.Join(messages, ", ")}
+	if len(messages) > 1 {
+		failure.Messages = messages
+	}
+	return failure
+}
+
+// messages returns the messages of the failure, or its comment if it has no messages.
+func (f Failure) messages() []string {
+	if len(f.Messages) > 0 {
+		return f.Messages
+	}
+	return []string{f.Comment}
+}
+
+// Pass returns the verdict of the failures according to their severity. Errors matching a known failure only cause an
+// XFAIL, and a known failure of the contract that did not occur causes an XPASS.
+func (c ContractResult) Pass(severity *Severity) ContractVerdict {
+	result := ContractPass
+	for _, failure := range c.Failures {
+		switch severity.Level(failure) {
+		case serialization.SeverityInfo:
+			result |= ContractInfo
+		case serialization.SeverityWarn:
+			result |= ContractWarn
+		case serialization.SeverityError:
+			if _, known := severity.knownTicket(failure); known {
+				result |= ContractXFail
+			} else {
+				result |= ContractFail
+			}
+		}
+	}
+	if severity.missingKnownFailure(c.Failures) {
+		result |= ContractXPass
+	}
+	return result
+}
+
+func combineHeaders(contract serialization.Contract, suite serialization.Suite) map[string]string {
+	headers := make(map[string]string)
+	for k, v := range suite.Headers {
+		headers[k] = v
+	}
+	for k, v := range contract.Headers {
+		headers[k] = v
+	}
+	return headers
+}
+
+// hasHeader checks if the header with the given name is set. Header names are case-insensitive.
+func hasHeader(headers map[string]string, name string) bool {
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// headerValue returns the value of the header with the given name, which is case-insensitive.
+func headerValue(headers map[string]string, name string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+// RunContract runs a contract and, if it has a retry policy, runs it again until it passes, the retry conditions are no
+// longer met or the maximum number of attempts is reached. If the context is done before the contract has run to
+// completion, the result is marked as skipped.
+func RunContract(
+	ctx context.Context,
+	contract serialization.Contract,
+	suite serialization.Suite,
+	severity *Severity,
+) ContractResult {
+	cr := runAttempt(ctx, contract, suite, severity)
+	if contract.Retry == nil {
+		return cr
+	}
+
+	failedAttempts := make([][]Failure, 0)
+	for attempt := 2; attempt <= contract.Retry.Attempts && shouldRetry(cr, *contract.Retry, severity); attempt++ {
+		if cr.Skipped || !sleep(ctx, contract.Retry.DelayBefore(attempt)) {
+			break
+		}
+		failedAttempts = append(failedAttempts, cr.Failures)
+		cr = runAttempt(ctx, contract, suite, severity)
+	}
+
+	cr.Attempts = len(failedAttempts) + 1
+	cr.FailedAttempts = failedAttempts
+	return cr
+}
+
+// runAttempt runs the contract once and checks the retry condition until against the response, also for contracts
+// with subcontracts.
+func runAttempt(
+	ctx context.Context,
+	contract serialization.Contract,
+	suite serialization.Suite,
+	severity *Severity,
+) ContractResult {
+	cr := runContract(ctx, contract, suite, severity)
+	if !cr.Skipped && cr.body != nil && contract.Retry != nil && len(contract.Retry.Until) > 0 {
+		cr.failure(checkUntil(cr.body, contract.Retry.Until))
+	}
+	cr.redactFailures()
+	return cr
+}
+
+// redactFailures redacts the secrets of the request and its response from the failures, e.g. from error messages
+// containing the URL or from debug output.
+func (c *ContractResult) redactFailures() {
+	for i, failure := range c.Failures {
+		c.Failures[i] = c.redact.failure(failure)
+	}
+}
+
+// shouldRetry checks if a contract should be run again after the given result. Results which pass are not retried.
+func shouldRetry(cr ContractResult, retry serialization.Retry, severity *Severity) bool {
+	if cr.Pass(severity) < ContractFail {
+		return false
+	}
+	if !retry.HasCondition() {
+		return true
+	}
+
+	for _, status := range retry.OnStatus {
+		if cr.StatusCode == status {
+			return true
+		}
+	}
+	for _, failure := range cr.Failures {
+		if failure.Reason == FailureBody && len(retry.Until) > 0 {
+			return true
+		}
+		for _, reason := range retry.OnReason {
+			if failure.Reason == FailureReason(reason) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NewSkippedResult creates the result of a contract which was not run to completion.
+func NewSkippedResult(contract serialization.Contract) ContractResult {
+	cr := NewContractResult(contract.Url)
+	if contract.Name != "" {
+		cr.Name = fmt.Sprintf("%s (%s)", contract.Name, contract.Url)
+	}
+	cr.Skipped = true
+	return cr
+}
+
+// sleep waits for the duration. It returns false if the context is done before.
+func sleep(ctx context.Context, duration time.Duration) bool {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func runContract(
+	ctx context.Context,
+	contract serialization.Contract,
+	suite serialization.Suite,
+	severity *Severity,
+) ContractResult {
+	if ctx.Err() != nil {
+		return NewSkippedResult(contract)
+	}
+	if len(contract.AnyOf) > 0 {
+		result := ContractResult{
+			Name:     contract.Name,
+			Failures: make([]Failure, 0),
+			Attempts: 1,
+		}
+		// An alternative with only known failures is returned if no other alternative passes, so a passing
+		// alternative can reveal a known failure that did not occur
+		var xfail *ContractResult
+		for _, subcontract := range contract.AnyOf {
+			subcontract := *subcontract
+			subcontract.Http = contract.Http.Merge(subcontract.Http)
+			subseverity := severity.forContract(subcontract)
+			cr := RunContract(ctx, subcontract, suite, subseverity)
+			if cr.Skipped {
+				return NewSkippedResult(contract)
+			}
+			verdict := cr.Pass(subseverity)
+			if verdict&(ContractXFail|ContractFail) == 0 {
+				return cr
+			}
+			if verdict < ContractFail {
+				if xfail == nil {
+					xfail = &cr
+				}
+				continue
+			}
+			result.Failures = append(result.Failures, cr.Failures...)
+			result.StatusCode = cr.StatusCode
+			result.ResponseTime = cr.ResponseTime
+			result.Exchange = cr.Exchange
+			result.body = cr.body
+			result.redact = cr.redact
+		}
+		if xfail != nil {
+			return *xfail
+		}
+		return result
+	}
+	if contract.Recorded != nil {
+		return runRecordedContract(contract, suite)
+	}
+	if strings.HasPrefix(contract.Url, "file://") {
+		return runFileContract(contract, suite)
+	}
+	return runHttpContract(ctx, contract, suite)
+}
+
+// runRecordedContract validates the recorded response of a contract, e.g. from a HAR file, instead of sending a request.
+func runRecordedContract(contract serialization.Contract, suite serialization.Suite) ContractResult {
+	cr := NewContractResult(contract.Name)
+	cr.Name = fmt.Sprintf("%s (%s %s)", contract.Name, strings.ToUpper(contract.Method), contract.Url)
+
+	recorded := contract.Recorded
+	if recorded.Error != "" {
+		cr.failure(FailureContract, recorded.Error)
+		return cr
+	}
+
+	cr.StatusCode = recorded.StatusCode
+	cr.ResponseTime = recorded.ResponseTime
+	res := &RequestResult{
+		StatusCode:   recorded.StatusCode,
+		Body:         recorded.Body,
+		ContentType:  recorded.ContentType,
+		ResponseTime: recorded.ResponseTime,
+		Headers:      http.Header{"Content-Type": {recorded.ContentType}},
+	}
+	cr.Exchange = newExchange(contract.Method, contract.Url, nil, nil, res, newRedactor(nil, suite.Redact))
+	checkHttpResponse(&cr, res, contract, suite)
+	return cr
+}
+
+func runFileContract(contract serialization.Contract, suite serialization.Suite) ContractResult {
+	cr := NewContractResult(contract.Name)
+	if cr.Name == "" {
+		cr.Name = contract.Url
+	}
+
+	content, err := ioutil.ReadFile(strings.TrimPrefix(contract.Url, "file://"))
+	if err != nil {
+		cr.failure(FailureIO, "")
+		return cr
+	}
+
+	contentType := contract.Expect.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(contract.Url))
+	}
+
+	if contract.Expect.SchemaName != "" || contract.Expect.SchemaResolved != nil {
+		cr.failureWithMessages(checkSchemaOnBody(content, contentType, contract, suite))
+	}
+
+	if contract.Expect.Xsd != "" {
+		cr.failureWithMessages(checkXsd(content, contract.Expect.Xsd))
+	}
+
+	if contract.Expect.Snapshot != "" {
+		cr.failure(checkSnapshot(content, contract.Expect.Snapshot, contract.Expect.SnapshotIgnore, suite.UpdateSnapshots))
+	}
+
+	return cr
+}
+
+func runHttpContract(ctx context.Context, contract serialization.Contract, suite serialization.Suite) ContractResult {
+	cr, res := sendHttpRequest(ctx, contract, suite)
+	if res != nil {
+		checkHttpResponse(&cr, res, contract, suite)
+	}
+	return cr
+}
+
+// sendHttpRequest sends the request of a contract. If no response was received, the response is nil and the reason is
+// reported as a failure of the result, unless the request was cancelled by the context.
+func sendHttpRequest(ctx context.Context, contract serialization.Contract, suite serialization.Suite) (ContractResult, *RequestResult) {
+	headers := combineHeaders(contract, suite)
+
+	query := make(map[string]string)
+	for key, value := range contract.Parameters {
+		if strings.HasPrefix(key, "path:") {
+			name := strings.TrimPrefix(key, "path:")
+			contract.Url = strings.ReplaceAll(contract.Url, "{"+name+"}", value)
+		} else if strings.HasPrefix(key, "header:") {
+			name := strings.TrimPrefix(key, "header:")
+			for headerKey, headerValue := range headers {
+				headers[headerKey] = strings.ReplaceAll(headerValue, "{"+name+"}", value)
+			}
+		} else if strings.HasPrefix(key, "query:") {
+			query[strings.TrimPrefix(key, "query:")] = value
+		}
+	}
+
+	cr := NewContractResult(contract.Name)
+	auths := contractAuths(contract, suite)
+	redact := newRedactor(auths, suite.Redact)
+	cr.redact = redact
+	client, err := HttpClient(suite.Http.Merge(contract.Http))
+	if err != nil {
+		cr.Name = redact.url(contract.Url)
+		cr.failure(FailureContract, err.Error())
+		return cr, nil
+	}
+	contract.Url = stripCredentials(contract.Url, contract.StripCredentials, headers, query)
+	authErr := applyAuth(ctx, client, auths, headers, query)
+	contract.Url = addQueryParameters(contract.Url, query)
+
+	// Credentials in the URL are not shown in the name of the result
+	if name := redact.url(contract.Url); contract.Name == "" {
+		cr.Name = name
+	} else {
+		cr.Name = fmt.Sprintf("%s (%s)", contract.Name, name)
+	}
+	if authErr != nil && ctx.Err() != nil {
+		cr.Skipped = true
+		return cr, nil
+	} else if authErr != nil {
+		cr.failure(FailureAuth, authErr.Error())
+		return cr, nil
+	}
+
+	for _, message := range checkParameterSchemas(contract) {
+		cr.failure(FailureContract, message)
+	}
+
+	body, contentType, err := EncodeBody(contract)
+	if err != nil {
+		cr.failure(FailureContract, err.Error())
+		return cr, nil
+	}
+	if body != nil && !hasHeader(headers, "Content-Type") {
+		headers["Content-Type"] = contentType
+	}
+
+	res, err := runRequestWithRetries(ctx, client, contract, headers, body, suite.Http.Merge(contract.Http))
+	cr.Exchange = newExchange(contract.Method, contract.Url, headers, body, res, redact)
+	if err != nil && ctx.Err() != nil {
+		cr.Skipped = true
+		return cr, nil
+	} else if err != nil {
+		cr.failure(FailureHttp, err.Error())
+		return cr, nil
+	}
+	cr.StatusCode = res.StatusCode
+	cr.ResponseTime = res.ResponseTime
+	return cr, res
+}
+
+// checkHttpResponse checks the response to the request of a contract against its expectations.
+func checkHttpResponse(cr *ContractResult, res *RequestResult, contract serialization.Contract, suite serialization.Suite) {
+	cr.body = res.Body
+	if !contract.Expect.MatchesStatus(res.StatusCode) {
+		cr.failure(FailureHttpStatus, fmt.Sprintf("got %d not %s", res.StatusCode, contract.Expect.ExpectedStatus()))
+		return
+	}
+
+	if contract.Expect.ContentType != "" && !openapi.MediaTypeMatches(res.ContentType, contract.Expect.ContentType) {
+		cr.failure(FailureContentType, fmt.Sprintf("got %s not %s", res.ContentType, contract.Expect.ContentType))
+	}
+
+	if contract.Debug {
+		cr.failure(FailureDebug, string(cr.redact.body(res.Body, res.ContentType)))
+	}
+
+	if contract.Expect.SchemaName != "" || contract.Expect.SchemaResolved != nil {
+		cr.failureWithMessages(checkSchemaOnBody(res.Body, res.ContentType, contract, suite))
+	}
+
+	if contract.Expect.Xsd != "" {
+		cr.failureWithMessages(checkXsd(res.Body, contract.Expect.Xsd))
+	}
+
+	if contract.Expect.Snapshot != "" {
+		cr.failure(checkSnapshot(res.Body, contract.Expect.Snapshot, contract.Expect.SnapshotIgnore, suite.UpdateSnapshots))
+	}
+
+	if max := contract.Expect.ResponseTime.Max; max > 0 && res.ResponseTime > max {
+		cr.failure(FailureResponseTime, fmt.Sprintf("took %dms not %dms", res.ResponseTime, max))
+	}
+}
+
+// runRequestWithRetries runs the request of the contract and sends it again, as often as the HttpConfig allows, if it
+// failed without a response.
+func runRequestWithRetries(
+	ctx context.Context,
+	client *http.Client,
+	contract serialization.Contract,
+	headers map[string]string,
+	body []byte,
+	config serialization.HttpConfig,
+) (*RequestResult, error) {
+	retries := 0
+	if config.Retries != nil {
+		retries = *config.Retries
+	}
+
+	res, err := RunRequest(ctx, client, contract.Method, contract.Url, headers, body)
+	for retry := 0; err != nil && retry < retries && ctx.Err() == nil; retry++ {
+		if config.RetryDelay != nil && !sleep(ctx, time.Duration(*config.RetryDelay)*time.Millisecond) {
+			break
+		}
+		res, err = RunRequest(ctx, client, contract.Method, contract.Url, headers, body)
+	}
+
+	// Back off if the API is rate limited, unless the contract expects the 429
+	for retry := 0; err == nil && retry < maxRateLimitRetries && shouldBackOff(res, contract); retry++ {
+		wait, _ := parseRetryAfter(res.RetryAfter)
+		if u, parseErr := url.Parse(contract.Url); parseErr == nil {
+			throttleFrom(ctx).pause(u.Host, wait)
+		}
+		if !sleep(ctx, wait) {
+			return nil, ctx.Err()
+		}
+		res, err = RunRequest(ctx, client, contract.Method, contract.Url, headers, body)
+	}
+	return res, err
+}
+
+// shouldBackOff checks if a response asks to send the request again later and the contract does not expect that.
+func shouldBackOff(res *RequestResult, contract serialization.Contract) bool {
+	if res.StatusCode != http.StatusTooManyRequests || contract.Expect.MatchesStatus(http.StatusTooManyRequests) {
+		return false
+	}
+	wait, ok := parseRetryAfter(res.RetryAfter)
+	return ok && wait <= maxRetryAfter
+}
+
+// checkUntil checks whether the values at the paths in the JSON data equal the expected values.
+func checkUntil(data []byte, until map[string]interface{}) (FailureReason, string) {
+	json, err := validation.JsonUnmarshal(data)
+	if err != nil {
+		return FailureFormat, ""
+	}
+
+	paths := make([]string, 0, len(until))
+	for path := range until {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	messages := make([]string, 0)
+	for _, path := range paths {
+		value, found := validation.JsonPathValue(json, path)
+		if !found {
+			messages = append(messages, "missing "+path)
+		} else if fmt.Sprint(value) != fmt.Sprint(until[path]) {
+			messages = append(messages, fmt.Sprintf("%s is %v not %v", path, value, until[path]))
+		}
+	}
+
+	if len(messages) > 0 {
+		return FailureBody, strings.Join(messages, ", ")
+	}
+	return "", ""
+}
+
+// addQueryParameters adds the given parameters to the query of rawUrl. If rawUrl can't be parsed, it is returned as is
+// and the request will report the error.
+func addQueryParameters(rawUrl string, parameters map[string]string) string {
+	if len(parameters) == 0 {
+		return rawUrl
+	}
+
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return rawUrl
+	}
+
+	query := u.Query()
+	for name, value := range parameters {
+		query.Set(name, value)
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// createArraySchema creates a new Schema of type array with the schema of the given name as Items.
+// The suffix `[]` is trimmed from the given schemaName.
+func createArraySchema(schemaName string, suite serialization.Suite) (openapi.Schema, bool) {
+	schemaName = strings.TrimSuffix(schemaName, "[]")
+
+	var itemSchema openapi.Schema
+	itemSchema, found := suite.Schemas[schemaName]
+
+	schema := openapi.Schema{
+		Items: &itemSchema,
+		Type:  openapi.SchemaTypeArray,
+	}
+	return schema, found
+}
+
+// findExpectedSchema returns the schema expected by the contract and whether it was found.
+func findExpectedSchema(contract serialization.Contract, suite serialization.Suite) (openapi.Schema, bool) {
+	schema, found := suite.Schemas[contract.Expect.SchemaName]
+	if contract.Expect.SchemaResolved != nil {
+		schema = *contract.Expect.SchemaResolved
+		found = true
+	}
+
+	// Create a new array schema; possible future optimization is creating array schemas when loading the suite.
+	if strings.HasSuffix(contract.Expect.SchemaName, "[]") {
+		schema, found = createArraySchema(contract.Expect.SchemaName, suite)
+	}
+
+	if schema.Title == "" {
+		schema.Title = "root"
+	}
+	return schema, found
+}
+
+// checkSchemaOnBody checks the schema on XML data if the content type is XML, otherwise on JSON data. It returns the
+// reason of the failure, if any, and its messages.
+func checkSchemaOnBody(data []byte, contentType string, contract serialization.Contract, suite serialization.Suite) (FailureReason, []string) {
+	if openapi.IsXmlMediaType(contentType) {
+		return checkSchemaOnXml(data, contract, suite)
+	}
+	return checkSchemaOnJson(data, contract, suite)
+}
+
+func checkSchemaOnJson(data []byte, contract serialization.Contract, suite serialization.Suite) (FailureReason, []string) {
+	schema, found := findExpectedSchema(contract, suite)
+
+	// Check if the schema specified in the contract was found
+	if !found {
+		return FailureContract, nil
+	}
+
+	json, err := validation.JsonUnmarshal(data)
+	// Check if data was valid JSON
+	if err != nil {
+		return FailureFormat, nil
+	}
+
+	// Check for valid JSON schema
+	messages := make([]string, 0)
+	if valid := validation.CheckSchema(schema, json, schema.Title, &messages); !valid {
+		return FailureSchema, messages
+	}
+
+	return "", nil
+}
+
+func checkSchemaOnXml(data []byte, contract serialization.Contract, suite serialization.Suite) (FailureReason, []string) {
+	schema, found := findExpectedSchema(contract, suite)
+
+	// Check if the schema specified in the contract was found
+	if !found {
+		return FailureContract, nil
+	}
+
+	value, rootName, err := XmlUnmarshal(data, &schema)
+	// Check if data was valid XML
+	if err != nil {
+		return FailureFormat, []string{err.Error()}
+	}
+
+	messages := make([]string, 0)
+	valid := validation.CheckSchema(schema, value, schema.Title, &messages)
+
+	if schema.Xml != nil && schema.Xml.Name != "" && schema.Xml.Name != rootName {
+		valid = false
+		messages = append(messages, fmt.Sprintf("%s is element %s not %s", schema.Title, rootName, schema.Xml.Name))
+	}
+
+	if !valid {
+		return FailureSchema, messages
+	}
+	return "", nil
+}
+
+// checkXsd validates XML data against the XML schema in the file at xsdPath.
+func checkXsd(data []byte, xsdPath string) (FailureReason, []string) {
+	xsd, err := loadXsd(xsdPath)
+	if err != nil {
+		return FailureContract, []string{err.Error()}
+	}
+
+	root, err := parseXml(data)
+	if err != nil {
+		return FailureFormat, []string{err.Error()}
+	}
+
+	messages := make([]string, 0)
+	if valid := xsd.Validate(root, &messages); !valid {
+		return FailureSchema, messages
+	}
+	return "", nil
+}
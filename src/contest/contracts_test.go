@@ -0,0 +1,59 @@
+package contest
+
+import (
+	"context"
+	"contract-testing/src/serialization"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAnyOfKnownFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		verdict  ContractVerdict
+		failures int
+	}{
+		{name: "second alternative passes", status: http.StatusCreated, verdict: ContractXPass, failures: 0},
+		{name: "no alternative passes", status: http.StatusInternalServerError, verdict: ContractXFail, failures: 1},
+	}
+
+	for _, test := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(test.status)
+		}))
+
+		suite := serialization.Suite{
+			KnownFailures: serialization.KnownFailures{{
+				Contract: "createPet",
+				Reason:   string(FailureHttpStatus),
+				Match:    "not 200",
+				Ticket:   "PET-1",
+				Expires:  "2999-12-31",
+			}},
+		}
+		contract := serialization.Contract{
+			Url:    server.URL + "/pets",
+			Method: http.MethodPost,
+			Name:   "createPet",
+			AnyOf: []*serialization.Contract{
+				{Url: server.URL + "/pets", Method: http.MethodPost, Expect: serialization.Expect{Status: http.StatusOK}},
+				{Url: server.URL + "/pets", Method: http.MethodPost, Expect: serialization.Expect{Status: http.StatusCreated}},
+			},
+		}
+
+		report, err := RunContracts(context.Background(), []serialization.Contract{contract}, suite, Options{})
+		server.Close()
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		result := report.Results[0]
+		if result.Verdict != test.verdict {
+			t.Errorf("%s: verdict %05b instead of %05b: %v", test.name, result.Verdict, test.verdict, result.Failures)
+		}
+		if len(result.Failures) != test.failures {
+			t.Errorf("%s: %d failures instead of %d: %v", test.name, len(result.Failures), test.failures, result.Failures)
+		}
+	}
+}
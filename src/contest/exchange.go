@@ -0,0 +1,335 @@
+package contest
+
+import (
+	"bytes"
+	"contract-testing/src/serialization"
+	"contract-testing/src/serialization/openapi"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxExchangeBody is the maximum number of bytes of a body kept in an Exchange.
+const maxExchangeBody = 64 * 1024
+
+// redacted replaces the values of credentials in an Exchange.
+const redacted = "[redacted]"
+
+// sensitivePattern matches the names of headers, query parameters and body fields whose values are redacted, e.g.
+// Authorization, X-Auth-Token, apiKey, client_secret or Set-Cookie.
+var sensitivePattern = regexp.MustCompile(`(?i)(^|[-_.])auth($|[-_.])|authori[sz]|authenticat|token|secret|passw(or)?d|` +
+	`api[-_]?key|access[-_]?key|private[-_]?key|credential|signature|cookie|session`)
+
+// Exchange is the request sent for a contract and the response received. Credentials and other secrets are redacted
+// and long bodies are truncated.
+type Exchange struct {
+	Request ExchangeRequest
+	// Response is nil if no response was received
+	Response *ExchangeResponse
+}
+
+type ExchangeRequest struct {
+	Method  string
+	Url     string
+	Headers map[string]string
+	Body    string
+}
+
+type ExchangeResponse struct {
+	StatusCode   int
+	Headers      map[string]string
+	Body         string
+	ResponseTime int64
+}
+
+// newExchange creates the Exchange of a request and its response, which may be nil.
+func newExchange(
+	method string,
+	rawUrl string,
+	headers map[string]string,
+	body []byte,
+	res *RequestResult,
+	redact redactor,
+) *Exchange {
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	exchange := &Exchange{
+		Request: ExchangeRequest{
+			Method:  strings.ToUpper(method),
+			Url:     redact.url(rawUrl),
+			Headers: redact.headers(headers),
+			Body:    truncateBody(redact.body(body, headerValue(headers, "Content-Type"))),
+		},
+	}
+	if res != nil {
+		responseHeaders := make(map[string]string, len(res.Headers))
+		for key, values := range res.Headers {
+			responseHeaders[key] = strings.Join(values, ", ")
+		}
+		exchange.Response = &ExchangeResponse{
+			StatusCode:   res.StatusCode,
+			Headers:      redact.headers(responseHeaders),
+			Body:         truncateBody(redact.body(res.Body, res.ContentType)),
+			ResponseTime: res.ResponseTime,
+		}
+	}
+	return exchange
+}
+
+// minSecretLength is the minimum length of a redacted value which is also redacted from failure comments, so short
+// values like true or 1 don't garble the comments.
+const minSecretLength = 4
+
+// redactor redacts the values of headers, query parameters and body fields whose names match sensitivePattern, are
+// API keys of the credentials or are redacted by the suite. The user info of URLs is removed. The values redacted so
+// far and the secrets of the credentials are redacted from failure comments as well.
+type redactor struct {
+	// names are the lower case names redacted in addition to those matching sensitivePattern
+	names []string
+	// secrets are the values redacted from failure comments, it is nil for the zero redactor
+	secrets *[]string
+}
+
+func newRedactor(auths serialization.Auths, redact []string) redactor {
+	names := make([]string, 0, len(auths)+len(redact))
+	for _, auth := range auths {
+		if auth.Type == serialization.AuthTypeApiKey {
+			names = append(names, strings.ToLower(auth.Name))
+		}
+	}
+	for _, name := range redact {
+		names = append(names, strings.ToLower(name))
+	}
+
+	r := redactor{names: names, secrets: &[]string{}}
+	for _, auth := range auths {
+		r.secret(auth.Password)
+		r.secret(auth.Token)
+		r.secret(auth.Value)
+		r.secret(auth.ClientSecret)
+	}
+	return r
+}
+
+// secret adds a value to the secrets redacted from failure comments. The credentials of header values like
+// "Bearer <token>" or "a=1; b=2" are added as well.
+func (r redactor) secret(value string) {
+	if r.secrets == nil || len(value) < minSecretLength || containsString(*r.secrets, value) {
+		return
+	}
+	*r.secrets = append(*r.secrets, value)
+
+	parts := strings.FieldsFunc(value, func(c rune) bool { return c == ';' || c == ',' })
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if i := strings.IndexAny(part, "= "); i >= 0 {
+			r.secret(strings.TrimSpace(part[i+1:]))
+		} else if len(parts) > 1 {
+			r.secret(part)
+		}
+	}
+}
+
+// comment redacts the secrets from a failure comment, the longest first.
+func (r redactor) comment(comment string) string {
+	if r.secrets == nil {
+		return comment
+	}
+	secrets := append([]string{}, *r.secrets...)
+	sort.Slice(secrets, func(i, j int) bool {
+		return len(secrets[i]) > len(secrets[j])
+	})
+	for _, secret := range secrets {
+		comment = strings.ReplaceAll(comment, secret, redacted)
+	}
+	return comment
+}
+
+// failure redacts the secrets from the comment and messages of a failure.
+func (r redactor) failure(failure Failure) Failure {
+	failure.Comment = r.comment(failure.Comment)
+	if len(failure.Messages) > 0 {
+		messages := make([]string, 0, len(failure.Messages))
+		for _, message := range failure.Messages {
+			messages = append(messages, r.comment(message))
+		}
+		failure.Messages = messages
+	}
+	return failure
+}
+
+func (r redactor) sensitive(name string) bool {
+	return sensitivePattern.MatchString(name) || containsString(r.names, strings.ToLower(name))
+}
+
+func (r redactor) headers(headers map[string]string) map[string]string {
+	redactedHeaders := make(map[string]string, len(headers))
+	for key, value := range headers {
+		if r.sensitive(key) {
+			r.secret(value)
+			value = redacted
+		}
+		redactedHeaders[key] = value
+	}
+	return redactedHeaders
+}
+
+// url removes the user info of the URL and redacts sensitive query parameters.
+func (r redactor) url(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return rawUrl
+	}
+	if password, found := u.User.Password(); found {
+		r.secret(password)
+	}
+	u.User = nil
+
+	query := u.Query()
+	changed := false
+	for name, values := range query {
+		if r.sensitive(name) {
+			for _, value := range values {
+				r.secret(value)
+			}
+			query.Set(name, redacted)
+			changed = true
+		}
+	}
+	if changed {
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+// body redacts sensitive fields of JSON, form and multipart bodies. Other bodies and bodies without sensitive fields are
+// returned unchanged.
+func (r redactor) body(body []byte, contentType string) []byte {
+	switch {
+	case len(body) == 0:
+		return body
+	case openapi.IsJsonMediaType(contentType):
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		var value interface{}
+		if err := decoder.Decode(&value); err != nil || !r.redactValue(value) {
+			return body
+		}
+		if redactedBody, err := json.Marshal(value); err == nil {
+			return redactedBody
+		}
+	case openapi.BaseMediaType(contentType) == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return body
+		}
+		changed := false
+		for name, fieldValues := range values {
+			if r.sensitive(name) {
+				for _, value := range fieldValues {
+					r.secret(value)
+				}
+				values.Set(name, redacted)
+				changed = true
+			}
+		}
+		if changed {
+			return []byte(values.Encode())
+		}
+	case openapi.BaseMediaType(contentType) == "multipart/form-data":
+		if redactedBody, changed := r.multipart(body, contentType); changed {
+			return redactedBody
+		}
+	}
+	return body
+}
+
+// multipart redacts the parts of a multipart body whose field names are sensitive. It returns false if no part was
+// redacted or the body is not valid.
+func (r redactor) multipart(body []byte, contentType string) ([]byte, bool) {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil || params["boundary"] == "" {
+		return nil, false
+	}
+
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	buffer := &bytes.Buffer{}
+	writer := multipart.NewWriter(buffer)
+	if err := writer.SetBoundary(params["boundary"]); err != nil {
+		return nil, false
+	}
+
+	changed := false
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, false
+		}
+		content, err := ioutil.ReadAll(part)
+		if err != nil {
+			return nil, false
+		}
+		if r.sensitive(part.FormName()) {
+			r.secret(string(content))
+			content = []byte(redacted)
+			changed = true
+		}
+		partWriter, err := writer.CreatePart(part.Header)
+		if err != nil {
+			return nil, false
+		}
+		_, _ = partWriter.Write(content)
+	}
+	if !changed || writer.Close() != nil {
+		return nil, false
+	}
+	return buffer.Bytes(), true
+}
+
+// redactValue redacts the sensitive fields of the objects in a JSON value. It returns true if a field was redacted.
+func (r redactor) redactValue(value interface{}) bool {
+	changed := false
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if r.sensitive(key) {
+				switch value := field.(type) {
+				case string:
+					r.secret(value)
+				case json.Number:
+					r.secret(value.String())
+				}
+				v[key] = redacted
+				changed = true
+			} else if r.redactValue(field) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if r.redactValue(item) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+func truncateBody(body []byte) string {
+	if len(body) <= maxExchangeBody {
+		return string(body)
+	}
+	return string(body[:maxExchangeBody]) + fmt.Sprintf("\n... %d more bytes", len(body)-maxExchangeBody)
+}
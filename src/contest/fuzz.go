@@ -0,0 +1,411 @@
+package contest
+
+import (
+	"context"
+	"contract-testing/src/serialization"
+	"contract-testing/src/serialization/openapi"
+	"contract-testing/src/validation"
+	"fmt"
+	"hash/fnv"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// fuzzMaxShrinkRuns is the maximum number of requests sent to shrink a failing input.
+const fuzzMaxShrinkRuns = 200
+
+// FuzzInput is a set of parameters and a request body sent to an operation.
+type FuzzInput struct {
+	// Parameters maps the parameters in the form location:name to their values
+	Parameters map[string]interface{}
+	Body       interface{}
+	// Mutation describes how the input was made invalid, it is empty for valid inputs
+	Mutation string
+}
+
+func (i FuzzInput) copy() FuzzInput {
+	parameters := make(map[string]interface{}, len(i.Parameters))
+	for key, value := range i.Parameters {
+		parameters[key] = deepCopyValue(value)
+	}
+	return FuzzInput{
+		Parameters: parameters,
+		Body:       deepCopyValue(i.Body),
+		Mutation:   i.Mutation,
+	}
+}
+
+func (i FuzzInput) String() string {
+	input := make(map[string]interface{})
+	if len(i.Parameters) > 0 {
+		input["parameters"] = i.Parameters
+	}
+	if i.Body != nil {
+		input["body"] = i.Body
+	}
+	data, err := validation.JsonMarshal(input)
+	if err != nil {
+		return fmt.Sprint(input)
+	}
+	return string(data)
+}
+
+// FuzzFailure is a shrunk input for which an operation failed.
+type FuzzFailure struct {
+	Input  FuzzInput
+	Result ContractResult
+}
+
+// fuzzLocation is a value of an input that can be mutated.
+type fuzzLocation struct {
+	Name     string
+	Schema   *openapi.Schema
+	Required bool
+	Set      func(value interface{})
+	// Remove removes the value from the input, it is nil for values which can't be removed
+	Remove func()
+}
+
+// FuzzOperation sends valid and mutated inputs to an operation. Every distinct kind of failure is shrunk to a minimal
+// input and returned. The inputs only depend on the seed and the id of the operation. Once the context is done, requests
+// in flight are cancelled and the failures found so far are returned.
+func FuzzOperation(ctx context.Context, target serialization.FuzzTarget, suite serialization.Suite, seed int64, runs int) []FuzzFailure {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(target.Contract.Name))
+	g := newGenerator(seed ^ int64(hash.Sum64()))
+
+	run := func(input FuzzInput) ContractResult {
+		cr := runFuzzInput(ctx, target, input, suite)
+		cr.redactFailures()
+		return cr
+	}
+
+	failures := make([]FuzzFailure, 0)
+	signatures := make(map[string]bool)
+	for i := 0; i < runs && ctx.Err() == nil; i++ {
+		input := g.validInput(target)
+		// The first input is always valid, three quarters of the others are mutated
+		if i > 0 && g.rand.Intn(4) != 0 {
+			input = g.mutateInput(input, target)
+		}
+
+		cr := run(input)
+		signature := fuzzSignature(cr)
+		if signature == "" || signatures[signature] {
+			continue
+		}
+		signatures[signature] = true
+
+		input, cr = shrinkInput(input, cr, run)
+		failures = append(failures, FuzzFailure{Input: input, Result: cr})
+	}
+	return failures
+}
+
+// runFuzzInput sends an input to an operation. The request fails if the API answers with a server error or a response
+// that does not match the documented response for its status.
+func runFuzzInput(ctx context.Context, target serialization.FuzzTarget, input FuzzInput, suite serialization.Suite) ContractResult {
+	contract := fuzzContract(target, input)
+	cr, res := sendHttpRequest(ctx, contract, suite)
+	if res == nil {
+		return cr
+	}
+
+	if res.StatusCode >= 500 {
+		cr.failure(FailureHttpStatus, fmt.Sprintf("server error %d", res.StatusCode))
+		return cr
+	}
+
+	key, found := serialization.FindResponseKey(target.Operation.Responses, res.StatusCode)
+	if !found {
+		cr.failure(FailureHttpStatus, fmt.Sprintf("undocumented status %d", res.StatusCode))
+		return cr
+	}
+
+	expected, err := serialization.NewContractFromOperationWithStatus(contract.Url, contract.Method, target.Operation, key)
+	if err != nil {
+		cr.failure(FailureContract, err.Error())
+		return cr
+	}
+	// For multiple media types, check the one the API answered with
+	for _, subcontract := range expected.AnyOf {
+		if openapi.MediaTypeMatches(res.ContentType, subcontract.Expect.ContentType) {
+			expected = subcontract
+			break
+		}
+	}
+	if len(expected.AnyOf) > 0 {
+		expected = expected.AnyOf[0]
+	}
+
+	checkHttpResponse(&cr, res, *expected, suite)
+	return cr
+}
+
+// fuzzContract creates the contract that sends the input to the operation.
+func fuzzContract(target serialization.FuzzTarget, input FuzzInput) serialization.Contract {
+	contract := target.Contract
+	contract.Headers = make(map[string]string, len(target.Contract.Headers))
+	for key, value := range target.Contract.Headers {
+		contract.Headers[key] = value
+	}
+
+	contract.Parameters = make(map[string]string, len(input.Parameters))
+	for key, value := range input.Parameters {
+		if strings.HasPrefix(key, string(openapi.ParameterInHeader)+":") {
+			contract.Headers[strings.TrimPrefix(key, string(openapi.ParameterInHeader)+":")] = parameterString(value)
+		} else if strings.HasPrefix(key, string(openapi.ParameterInPath)+":") {
+			contract.Parameters[key] = url.PathEscape(parameterString(value))
+		} else {
+			contract.Parameters[key] = parameterString(value)
+		}
+	}
+	contract.Body = input.Body
+	return contract
+}
+
+// parameterString converts the value of a parameter into its string representation. Arrays are separated by commas.
+func parameterString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []interface{}:
+		values := make([]string, len(v))
+		for i, item := range v {
+			values[i] = parameterString(item)
+		}
+		return strings.Join(values, ",")
+	case map[string]interface{}:
+		data, _ := validation.JsonMarshal(v)
+		return string(data)
+	}
+	return fmt.Sprint(value)
+}
+
+// fuzzSignature describes the kind of failure of a result. Results with the same signature are the same failure. An
+// empty signature means the result has no failures.
+func fuzzSignature(cr ContractResult) string {
+	parts := make([]string, 0, len(cr.Failures))
+	for _, failure := range cr.Failures {
+		if failure.Reason == FailureHttpStatus {
+			parts = append(parts, failure.String())
+		} else if failure.Reason != FailureDebug {
+			parts = append(parts, string(failure.Reason))
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ";")
+}
+
+// validInput generates an input that matches the schemas of the operation. Values from the spec file are used as they
+// are, optional parameters and bodies are left out at random.
+func (g *generator) validInput(target serialization.FuzzTarget) FuzzInput {
+	input := FuzzInput{Parameters: make(map[string]interface{})}
+	for _, parameter := range target.Parameters {
+		if parameter.In == openapi.ParameterInCookie {
+			continue
+		}
+
+		key := string(parameter.In) + ":" + parameter.Name
+		if value, found := target.Contract.Parameters[key]; found {
+			input.Parameters[key] = value
+		} else if value, found := target.Contract.Parameters[parameter.Name]; found {
+			input.Parameters[key] = value
+		} else if parameter.Required || parameter.In == openapi.ParameterInPath || g.rand.Intn(2) == 0 {
+			input.Parameters[key] = g.value(parameter.Schema, 0)
+		}
+	}
+
+	if target.BodySchema == nil {
+		input.Body = target.Contract.Body
+	} else if target.Contract.Body != nil {
+		input.Body = normalizeValue(deepCopyValue(target.Contract.Body))
+	} else if target.Operation.RequestBody.Required || g.rand.Intn(2) == 0 {
+		input.Body = g.value(target.BodySchema, 0)
+	}
+	return input
+}
+
+// mutateInput returns a copy of the input in which one value at random is mutated to no longer match its schema.
+func (g *generator) mutateInput(input FuzzInput, target serialization.FuzzTarget) FuzzInput {
+	mutated := input.copy()
+	locations := fuzzLocations(&mutated, target)
+	if len(locations) == 0 {
+		return mutated
+	}
+
+	location := locations[g.rand.Intn(len(locations))]
+	applicable := mutations(location.Schema)
+	if location.Required && location.Remove != nil {
+		applicable = append(applicable, MutationMissing)
+	}
+
+	mutation := applicable[g.rand.Intn(len(applicable))]
+	if mutation == MutationMissing {
+		location.Remove()
+	} else {
+		location.Set(g.mutate(location.Schema, mutation))
+	}
+	mutated.Mutation = fmt.Sprintf("%s at %s", mutation, location.Name)
+	return mutated
+}
+
+// fuzzLocations returns the parameters and values in the body of an input that can be mutated.
+func fuzzLocations(input *FuzzInput, target serialization.FuzzTarget) []fuzzLocation {
+	locations := make([]fuzzLocation, 0)
+	for _, parameter := range target.Parameters {
+		key := string(parameter.In) + ":" + parameter.Name
+		if parameter.In == openapi.ParameterInCookie {
+			continue
+		}
+
+		location := fuzzLocation{
+			Name:     key,
+			Schema:   parameter.Schema,
+			Required: parameter.Required,
+			Set: func(value interface{}) {
+				input.Parameters[key] = value
+			},
+		}
+		// Without a path parameter, the request would be sent to another path
+		if parameter.In != openapi.ParameterInPath {
+			location.Remove = func() {
+				delete(input.Parameters, key)
+			}
+		}
+		locations = append(locations, location)
+	}
+
+	if target.BodySchema == nil || input.Body == nil {
+		return locations
+	}
+
+	// Only a JSON body can have another type, forms have to be objects
+	if target.Contract.BodyType == "" || target.Contract.BodyType == serialization.BodyTypeJson {
+		locations = append(locations, fuzzLocation{
+			Name:     "body",
+			Schema:   target.BodySchema,
+			Required: target.Operation.RequestBody.Required,
+			Set: func(value interface{}) {
+				input.Body = value
+			},
+			Remove: func() {
+				input.Body = nil
+			},
+		})
+	}
+	return append(locations, valueLocations("body", target.BodySchema, input.Body)...)
+}
+
+// valueLocations returns the locations of the properties and items nested in a value.
+func valueLocations(name string, schema *openapi.Schema, value interface{}) []fuzzLocation {
+	locations := make([]fuzzLocation, 0)
+	switch v := value.(type) {
+	case map[string]interface{}:
+		properties, required := schema.AllProperties()
+		names := make([]string, 0, len(properties))
+		for property := range properties {
+			names = append(names, property)
+		}
+		sort.Strings(names)
+
+		for _, property := range names {
+			child, found := v[property]
+			if !found {
+				continue
+			}
+			property := property
+			locations = append(locations, fuzzLocation{
+				Name:     name + "." + property,
+				Schema:   properties[property],
+				Required: required[property],
+				Set: func(value interface{}) {
+					v[property] = value
+				},
+				Remove: func() {
+					delete(v, property)
+				},
+			})
+			locations = append(locations, valueLocations(name+"."+property, properties[property], child)...)
+		}
+	case []interface{}:
+		if schema == nil || schema.Items == nil {
+			break
+		}
+		for i := range v {
+			i := i
+			locations = append(locations, fuzzLocation{
+				Name:   fmt.Sprintf("%s[%d]", name, i),
+				Schema: schema.Items,
+				Set: func(value interface{}) {
+					v[i] = value
+				},
+			})
+			locations = append(locations, valueLocations(fmt.Sprintf("%s[%d]", name, i), schema.Items, v[i])...)
+		}
+	}
+	return locations
+}
+
+// shrinkInput simplifies a failing input as long as it still fails with the same signature as its result. It returns
+// the simplest input found and its result.
+func shrinkInput(input FuzzInput, result ContractResult, run func(FuzzInput) ContractResult) (FuzzInput, ContractResult) {
+	signature := fuzzSignature(result)
+	for runs := 0; runs < fuzzMaxShrinkRuns; {
+		shrunk := false
+		for _, candidate := range shrinkCandidates(input) {
+			runs++
+			cr := run(candidate)
+			if fuzzSignature(cr) == signature {
+				input, result, shrunk = candidate, cr, true
+				break
+			}
+			if runs >= fuzzMaxShrinkRuns {
+				break
+			}
+		}
+		if !shrunk {
+			break
+		}
+	}
+	return input, result
+}
+
+// shrinkCandidates returns simpler variants of an input. Path parameters are never removed.
+func shrinkCandidates(input FuzzInput) []FuzzInput {
+	keys := make([]string, 0, len(input.Parameters))
+	for key := range input.Parameters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	candidates := make([]FuzzInput, 0)
+	for _, key := range keys {
+		if strings.HasPrefix(key, string(openapi.ParameterInPath)+":") {
+			continue
+		}
+		candidate := input.copy()
+		delete(candidate.Parameters, key)
+		candidates = append(candidates, candidate)
+	}
+	if input.Body != nil {
+		candidate := input.copy()
+		candidate.Body = nil
+		candidates = append(candidates, candidate)
+	}
+
+	for _, key := range keys {
+		for _, value := range shrinkValue(input.Parameters[key]) {
+			candidate := input.copy()
+			candidate.Parameters[key] = value
+			candidates = append(candidates, candidate)
+		}
+	}
+	for _, body := range shrinkValue(input.Body) {
+		candidate := input.copy()
+		candidate.Body = body
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
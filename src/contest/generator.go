@@ -0,0 +1,407 @@
+package contest
+
+import (
+	"contract-testing/src/serialization/openapi"
+	"contract-testing/src/validation"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+const (
+	// generatorMaxDepth is the depth of nested values after which only required properties are generated. Deeper than
+	// twice this depth, no values are generated at all, which ends recursive schemas with required properties.
+	generatorMaxDepth = 4
+	// generatorOverlongLength is the length of an overlong string for a schema without a maxLength
+	generatorOverlongLength = 10000
+)
+
+// generator generates values from schemas and mutates them into values that do not match their schema.
+type generator struct {
+	rand *rand.Rand
+}
+
+func newGenerator(seed int64) *generator {
+	return &generator{rand: rand.New(rand.NewSource(seed))}
+}
+
+// value generates a random value that matches the schema.
+func (g *generator) value(schema *openapi.Schema, depth int) interface{} {
+	if schema == nil {
+		return g.string(nil)
+	}
+	if depth > 2*generatorMaxDepth {
+		return nil
+	}
+
+	if len(schema.Enum) > 0 {
+		return normalizeValue(schema.Enum[g.rand.Intn(len(schema.Enum))])
+	}
+	if schema.Example != nil && g.rand.Intn(2) == 0 {
+		return normalizeValue(schema.Example)
+	}
+
+	// Subschemas are one level deeper, so recursive references through allOf, anyOf and oneOf end as well
+	switch {
+	case len(schema.AllOf) > 0:
+		merged := make(map[string]interface{})
+		for _, subschema := range schema.AllOf {
+			if object, ok := g.value(subschema, depth+1).(map[string]interface{}); ok {
+				for key, value := range object {
+					merged[key] = value
+				}
+			}
+		}
+		return merged
+	case len(schema.AnyOf) > 0:
+		return g.value(schema.AnyOf[g.rand.Intn(len(schema.AnyOf))], depth+1)
+	case len(schema.OneOf) > 0:
+		return g.value(schema.OneOf[g.rand.Intn(len(schema.OneOf))], depth+1)
+	}
+
+	switch schema.Type {
+	case openapi.SchemaTypeObject:
+		return g.object(schema, depth)
+	case openapi.SchemaTypeArray:
+		return g.array(schema, depth)
+	case openapi.SchemaTypeInteger:
+		return g.integer(schema)
+	case openapi.SchemaTypeNumber:
+		return g.number(schema)
+	case openapi.SchemaTypeBoolean:
+		return g.rand.Intn(2) == 0
+	case openapi.SchemaTypeString:
+		return g.string(schema)
+	}
+	if len(schema.Properties) > 0 {
+		return g.object(schema, depth)
+	}
+	return g.string(schema)
+}
+
+func (g *generator) object(schema *openapi.Schema, depth int) map[string]interface{} {
+	object := make(map[string]interface{})
+	for _, name := range sortedPropertyNames(schema) {
+		required := containsString(schema.Required, name)
+		if !required && (depth >= generatorMaxDepth || g.rand.Intn(2) == 0) {
+			continue
+		}
+		object[name] = g.value(schema.Properties[name], depth+1)
+	}
+	return object
+}
+
+func (g *generator) array(schema *openapi.Schema, depth int) []interface{} {
+	minItems, maxItems := 0, 3
+	if schema.MinItems != nil {
+		minItems = *schema.MinItems
+		maxItems = minItems + 3
+	}
+	if schema.MaxItems != nil && *schema.MaxItems < maxItems {
+		maxItems = *schema.MaxItems
+	}
+	if depth >= generatorMaxDepth {
+		maxItems = minItems
+	}
+
+	count := minItems
+	if maxItems > minItems {
+		count += g.rand.Intn(maxItems - minItems + 1)
+	}
+	array := make([]interface{}, count)
+	for i := range array {
+		array[i] = g.value(schema.Items, depth+1)
+	}
+	return array
+}
+
+// bounds returns the range of valid numbers of the schema. Without a minimum or maximum, a range around it is used.
+func bounds(schema *openapi.Schema, step float64) (float64, float64) {
+	min, max := 0.0, 100.0
+	if schema.Minimum != nil {
+		min = *schema.Minimum
+		if schema.ExclusiveMinimum {
+			min += step
+		}
+		if schema.Maximum == nil {
+			max = min + 100
+		}
+	}
+	if schema.Maximum != nil {
+		max = *schema.Maximum
+		if schema.ExclusiveMaximum {
+			max -= step
+		}
+		if schema.Minimum == nil {
+			min = max - 100
+		}
+	}
+	return min, max
+}
+
+// maxInteger is the largest absolute value of generated integers, so the size of their range fits into an int64.
+const maxInteger = 1<<62 - 1
+
+func (g *generator) integer(schema *openapi.Schema) int64 {
+	min, max := bounds(schema, 1)
+	low, high := clampInteger(math.Ceil(min)), clampInteger(math.Floor(max))
+	if high <= low {
+		return low
+	}
+	return low + g.rand.Int63n(high-low+1)
+}
+
+// clampInteger converts the value to an int64 between -maxInteger and maxInteger.
+func clampInteger(value float64) int64 {
+	if value >= maxInteger {
+		return maxInteger
+	}
+	if value <= -maxInteger {
+		return -maxInteger
+	}
+	return int64(value)
+}
+
+func (g *generator) number(schema *openapi.Schema) float64 {
+	min, max := bounds(schema, 0.01)
+	return math.Round((min+g.rand.Float64()*(max-min))*100) / 100
+}
+
+func (g *generator) string(schema *openapi.Schema) string {
+	if schema != nil {
+		switch schema.Format {
+		case openapi.SchemaFormatUri:
+			return "https://example.com/" + g.letters(8)
+		case "date":
+			return fmt.Sprintf("20%02d-%02d-%02d", g.rand.Intn(100), g.rand.Intn(12)+1, g.rand.Intn(28)+1)
+		case "date-time":
+			return fmt.Sprintf("20%02d-%02d-%02dT%02d:%02d:00Z", g.rand.Intn(100), g.rand.Intn(12)+1, g.rand.Intn(28)+1, g.rand.Intn(24), g.rand.Intn(60))
+		case "email":
+			return g.letters(8) + "@example.com"
+		case "uuid":
+			return fmt.Sprintf("%08x-%04x-4%03x-8%03x-%012x", g.rand.Uint32(), g.rand.Intn(1<<16), g.rand.Intn(1<<12), g.rand.Intn(1<<12), g.rand.Int63n(1<<48))
+		}
+	}
+
+	minLength, maxLength := 1, 12
+	if schema != nil && schema.MinLength != nil {
+		minLength = *schema.MinLength
+		maxLength = minLength + 12
+	}
+	if schema != nil && schema.MaxLength != nil && *schema.MaxLength < maxLength {
+		maxLength = *schema.MaxLength
+	}
+	length := minLength
+	if maxLength > minLength {
+		length += g.rand.Intn(maxLength - minLength + 1)
+	}
+	return g.letters(length)
+}
+
+func (g *generator) letters(length int) string {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz"
+	builder := strings.Builder{}
+	for i := 0; i < length; i++ {
+		builder.WriteByte(alphabet[g.rand.Intn(len(alphabet))])
+	}
+	return builder.String()
+}
+
+// Mutation is a way in which a value is changed to no longer match its schema.
+type Mutation string
+
+const (
+	MutationMissing    Mutation = "missing required" // A required value is removed
+	MutationWrongType  Mutation = "wrong type"       // A value of another type or null
+	MutationOverlong   Mutation = "overlong string"  // A string longer than its maxLength
+	MutationOutOfRange Mutation = "out of range"     // A number beyond its minimum or maximum
+)
+
+// mutations returns the mutations that make a value of the schema invalid. MutationMissing is only applicable to
+// required values and has to be handled by the caller.
+func mutations(schema *openapi.Schema) []Mutation {
+	applicable := []Mutation{MutationWrongType}
+	if schema == nil {
+		return applicable
+	}
+	switch schema.Type {
+	case openapi.SchemaTypeString:
+		if len(schema.Enum) == 0 {
+			applicable = append(applicable, MutationOverlong)
+		}
+	case openapi.SchemaTypeInteger, openapi.SchemaTypeNumber:
+		applicable = append(applicable, MutationOutOfRange)
+	}
+	return applicable
+}
+
+// mutate returns a value that does not match the schema according to the mutation.
+func (g *generator) mutate(schema *openapi.Schema, mutation Mutation) interface{} {
+	switch mutation {
+	case MutationOverlong:
+		length := generatorOverlongLength
+		if schema.MaxLength != nil {
+			length = *schema.MaxLength + 1
+		}
+		return strings.Repeat("a", length)
+	case MutationOutOfRange:
+		return g.outOfRange(schema)
+	}
+	return g.wrongType(schema)
+}
+
+func (g *generator) outOfRange(schema *openapi.Schema) interface{} {
+	candidates := make([]float64, 0, 2)
+	if schema.Minimum != nil {
+		below := *schema.Minimum - 1
+		if schema.ExclusiveMinimum {
+			below = *schema.Minimum
+		}
+		candidates = append(candidates, below)
+	}
+	if schema.Maximum != nil {
+		above := *schema.Maximum + 1
+		if schema.ExclusiveMaximum {
+			above = *schema.Maximum
+		}
+		candidates = append(candidates, above)
+	}
+	if len(candidates) == 0 {
+		candidates = append(candidates, -math.MaxInt64, math.MaxInt64)
+	}
+
+	value := candidates[g.rand.Intn(len(candidates))]
+	if schema.Type == openapi.SchemaTypeInteger && math.Abs(value) < math.MaxInt64 {
+		return int64(value)
+	}
+	return value
+}
+
+func (g *generator) wrongType(schema *openapi.Schema) interface{} {
+	candidates := []interface{}{nil}
+	if schema == nil || schema.Type != openapi.SchemaTypeString {
+		candidates = append(candidates, "contest")
+	}
+	if schema == nil || (schema.Type != openapi.SchemaTypeInteger && schema.Type != openapi.SchemaTypeNumber) {
+		candidates = append(candidates, int64(12345))
+	}
+	if schema == nil || schema.Type != openapi.SchemaTypeBoolean {
+		candidates = append(candidates, true)
+	}
+	if schema == nil || schema.Type != openapi.SchemaTypeObject {
+		candidates = append(candidates, map[string]interface{}{"contest": "fuzz"})
+	}
+	if schema == nil || schema.Type != openapi.SchemaTypeArray {
+		candidates = append(candidates, []interface{}{"contest"})
+	}
+	if schema != nil && schema.Nullable {
+		candidates = candidates[1:]
+	}
+	return candidates[g.rand.Intn(len(candidates))]
+}
+
+// shrinkValue returns simpler variants of a value, with the greatest simplifications first.
+func shrinkValue(value interface{}) []interface{} {
+	shrunk := make([]interface{}, 0)
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			without := deepCopyValue(v).(map[string]interface{})
+			delete(without, key)
+			shrunk = append(shrunk, without)
+		}
+		for _, key := range keys {
+			for _, child := range shrinkValue(v[key]) {
+				replaced := deepCopyValue(v).(map[string]interface{})
+				replaced[key] = child
+				shrunk = append(shrunk, replaced)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			without := append(append(make([]interface{}, 0, len(v)-1), v[:i]...), v[i+1:]...)
+			shrunk = append(shrunk, deepCopyValue(without))
+		}
+		for i := range v {
+			for _, child := range shrinkValue(v[i]) {
+				replaced := deepCopyValue(v).([]interface{})
+				replaced[i] = child
+				shrunk = append(shrunk, replaced)
+			}
+		}
+	case string:
+		if len(v) > 0 {
+			shrunk = append(shrunk, "")
+		}
+		if len(v) > 1 {
+			shrunk = append(shrunk, v[:len(v)/2])
+		}
+	case int64:
+		if v != 0 {
+			shrunk = append(shrunk, int64(0))
+		}
+		if v > 1 || v < -1 {
+			shrunk = append(shrunk, v/2)
+		}
+	case float64:
+		if v != 0 {
+			shrunk = append(shrunk, 0.0)
+		}
+		if math.Abs(v) > 1 {
+			shrunk = append(shrunk, math.Trunc(v/2))
+		}
+	case bool:
+		if v {
+			shrunk = append(shrunk, false)
+		}
+	}
+	return shrunk
+}
+
+// deepCopyValue copies the maps and arrays of a value, such that it can be modified.
+func deepCopyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			copied[key] = deepCopyValue(child)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, child := range v {
+			copied[i] = deepCopyValue(child)
+		}
+		return copied
+	}
+	return value
+}
+
+// normalizeValue converts a value from YAML into the same kind of values JsonUnmarshal returns.
+func normalizeValue(value interface{}) interface{} {
+	data, err := validation.JsonMarshal(value)
+	if err != nil {
+		return value
+	}
+	normalized, err := validation.JsonUnmarshal(data)
+	if err != nil {
+		return value
+	}
+	return normalized
+}
+
+func sortedPropertyNames(schema *openapi.Schema) []string {
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
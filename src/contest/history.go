@@ -0,0 +1,197 @@
+package contest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// historyLength is the number of verdicts kept per contract in a saved run to detect flaky contracts.
+const historyLength = 10
+
+// minRegression is the increase of a response time in ms below which it is never reported as regression, so fast
+// responses don't cause regressions because of noise.
+const minRegression = 10
+
+// SavedRun is the JSON form of the results of a run. Contracts are identified by their key, or by the name of their
+// result in runs saved without keys.
+type SavedRun struct {
+	Time      time.Time     `json:"time"`
+	Contracts []SavedResult `json:"contracts"`
+}
+
+type SavedResult struct {
+	// Key is the name of the contract, or its method and URL template if it is unnamed
+	Key      string   `json:"key"`
+	Name     string   `json:"name"`
+	Verdict  string   `json:"verdict"`
+	Failures []string `json:"failures,omitempty"`
+	// ResponseTime is the response time in ms, the median in load mode
+	ResponseTime int64 `json:"responseTime"`
+	// History contains the verdicts of the last runs of the contract, including this one, the latest last
+	History []string `json:"history"`
+}
+
+// NewSavedRun creates the saved form of the report. The verdict history of every contract is continued from the
+// baseline, which may be nil.
+func NewSavedRun(report *Report, baseline *SavedRun) SavedRun {
+	previous := make(map[string]SavedResult)
+	if baseline != nil {
+		previous = baseline.results()
+	}
+
+	run := SavedRun{Time: time.Now(), Contracts: make([]SavedResult, 0, len(report.Results))}
+	for _, result := range report.Results {
+		saved := SavedResult{
+			Key:          result.key,
+			Name:         result.Name,
+			Verdict:      verdictName(result.Verdict, result.Skipped),
+			ResponseTime: result.ResponseTime,
+		}
+		for _, failure := range result.Failures {
+			saved.Failures = append(saved.Failures, failure.String())
+		}
+		if result.Load != nil {
+			saved.ResponseTime = result.Load.Percentile(50)
+		}
+
+		base, _ := findResult(previous, saved)
+		history := append([]string{}, base.History...)
+		if !result.Skipped {
+			history = append(history, saved.Verdict)
+		}
+		if len(history) > historyLength {
+			history = history[len(history)-historyLength:]
+		}
+		saved.History = history
+		run.Contracts = append(run.Contracts, saved)
+	}
+	return run
+}
+
+func (r SavedRun) results() map[string]SavedResult {
+	results := make(map[string]SavedResult, len(r.Contracts))
+	for _, result := range r.Contracts {
+		if _, found := results[result.id()]; !found {
+			results[result.id()] = result
+		}
+	}
+	return results
+}
+
+// findResult returns the result of the contract of a result in results created with SavedRun.results. Results saved
+// without keys are found by name.
+func findResult(results map[string]SavedResult, result SavedResult) (SavedResult, bool) {
+	if saved, found := results[result.id()]; found {
+		return saved, true
+	}
+	saved, found := results[result.Name]
+	return saved, found && saved.Key == ""
+}
+
+// id returns the key of the result, or its name if the run was saved without keys.
+func (r SavedResult) id() string {
+	if r.Key != "" {
+		return r.Key
+	}
+	return r.Name
+}
+
+// LoadRun reads a run saved with SaveRun.
+func LoadRun(path string) (*SavedRun, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var run SavedRun
+	if err := json.Unmarshal(data, &run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// SaveRun writes the run as indented JSON, so it can be reviewed and committed.
+func SaveRun(path string, run SavedRun) error {
+	data, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Comparison lists the changes of a run compared with a baseline run.
+type Comparison struct {
+	// NewFailures are the contracts which fail, but did not fail in the baseline or are new
+	NewFailures []string
+	// NewPasses are the contracts which failed in the baseline and pass now
+	NewPasses []string
+	// Flaky are the contracts whose verdict alternated between pass and fail in their history. They are listed as new
+	// failures or new passes as well, this only explains the change.
+	Flaky       []string
+	Regressions []Regression
+}
+
+// Regression is a response time which increased beyond the threshold.
+type Regression struct {
+	Name     string
+	Baseline int64
+	Current  int64
+}
+
+// Compare compares the run with the baseline. A response time is a regression if it increased by more than threshold
+// percent and at least minRegression ms. Skipped contracts are not compared.
+func Compare(baseline SavedRun, run SavedRun, threshold float64) Comparison {
+	comparison := Comparison{
+		NewFailures: make([]string, 0),
+		NewPasses:   make([]string, 0),
+		Flaky:       make([]string, 0),
+		Regressions: make([]Regression, 0),
+	}
+
+	previous := baseline.results()
+	for _, result := range run.Contracts {
+		if result.Verdict == "SKIP" {
+			continue
+		}
+		base, found := findResult(previous, result)
+		failed := result.Verdict == "FAIL"
+
+		if failed && (!found || base.Verdict != "FAIL") {
+			comparison.NewFailures = append(comparison.NewFailures, result.Name)
+		} else if !failed && found && base.Verdict == "FAIL" {
+			comparison.NewPasses = append(comparison.NewPasses, result.Name)
+		}
+		if isFlaky(result.History) {
+			comparison.Flaky = append(comparison.Flaky, result.Name)
+		}
+
+		if !found || base.Verdict == "SKIP" || base.ResponseTime == 0 {
+			continue
+		}
+		increase := result.ResponseTime - base.ResponseTime
+		if increase >= minRegression && float64(increase) > float64(base.ResponseTime)*threshold/100 {
+			comparison.Regressions = append(comparison.Regressions, Regression{
+				Name:     result.Name,
+				Baseline: base.ResponseTime,
+				Current:  result.ResponseTime,
+			})
+		}
+	}
+	return comparison
+}
+
+// isFlaky checks if the verdicts changed between pass and fail at least twice.
+func isFlaky(history []string) bool {
+	changes := 0
+	for i := 1; i < len(history); i++ {
+		if (history[i] == "FAIL") != (history[i-1] == "FAIL") {
+			changes++
+		}
+	}
+	return changes >= 2
+}
@@ -0,0 +1,231 @@
+package contest
+
+import (
+	"context"
+	"contract-testing/src/serialization"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// histogramBounds are the upper bounds in ms of the buckets of a latency histogram. Slower responses are counted in a
+// last bucket without bound.
+var histogramBounds = []int64{1, 2, 5, 10, 20, 50, 100, 200, 500, 1000, 2000, 5000, 10000}
+
+// LoadStats summarizes the runs of a contract in load mode.
+type LoadStats struct {
+	Runs int
+	// Errors is the number of runs that failed
+	Errors   int
+	Duration time.Duration
+	// Latencies are the response times in ms of all runs with a response, in ascending order
+	Latencies []int64
+}
+
+// ErrorRate returns the fraction of the runs that failed.
+func (s LoadStats) ErrorRate() float64 {
+	if s.Runs == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Runs)
+}
+
+// Percentile returns the response time in ms below which the given percentage of the responses were received, using
+// the nearest-rank method.
+func (s LoadStats) Percentile(percentile float64) int64 {
+	return latencyPercentile(s.Latencies, percentile)
+}
+
+// HistogramBucket counts the responses with a response time of at most UpperBound ms, but more than the bound of the
+// previous bucket. The last bucket has the UpperBound -1.
+type HistogramBucket struct {
+	UpperBound int64
+	Count      int
+}
+
+// Histogram returns the distribution of the response times, from the first to the last non-empty bucket.
+func (s LoadStats) Histogram() []HistogramBucket {
+	buckets := make([]HistogramBucket, len(histogramBounds)+1)
+	for i, bound := range histogramBounds {
+		buckets[i].UpperBound = bound
+	}
+	buckets[len(histogramBounds)].UpperBound = -1
+
+	for _, latency := range s.Latencies {
+		i := sort.Search(len(histogramBounds), func(i int) bool {
+			return histogramBounds[i] >= latency
+		})
+		buckets[i].Count++
+	}
+
+	first, last := -1, -1
+	for i, bucket := range buckets {
+		if bucket.Count > 0 {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if first < 0 {
+		return nil
+	}
+	return buckets[first : last+1]
+}
+
+// latencyPercentile returns the percentile of the sorted latencies using the nearest-rank method.
+func latencyPercentile(latencies []int64, percentile float64) int64 {
+	if len(latencies) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(percentile / 100 * float64(len(latencies))))
+	if rank < 1 {
+		rank = 1
+	}
+	return latencies[rank-1]
+}
+
+// checkPercentiles checks the percentiles of the response times against the expected response time.
+func checkPercentiles(cr *ContractResult, expect serialization.ResponseTime, latencies []int64) {
+	if len(latencies) == 0 {
+		return
+	}
+	for _, percentile := range expect.SortedPercentiles() {
+		limit := expect.Percentiles[percentile]
+		if value := latencyPercentile(latencies, percentile); value > limit {
+			name := serialization.PercentileName(percentile)
+			cr.failure(FailureResponseTime, fmt.Sprintf("%s took %dms not %dms", name, value, limit))
+		}
+	}
+}
+
+// tookPattern matches the measured response time in the comment of a response time failure.
+var tookPattern = regexp.MustCompile(`took (\d+)ms`)
+
+// loadFailure groups the failures of the runs of a load test which have the same reason and comment. The measured
+// response time of response time failures is ignored, the slowest of them is reported.
+type loadFailure struct {
+	failure     Failure
+	occurrences int
+	// slowest is the longest response time of response time failures
+	slowest int64
+}
+
+// loadFailureKey returns the key by which failures of the runs of a load test are grouped.
+func loadFailureKey(failure Failure) string {
+	comment := failure.Comment
+	if failure.Reason == FailureResponseTime {
+		comment = tookPattern.ReplaceAllString(comment, "took ?ms")
+	}
+	return string(failure.Reason) + "|" + comment
+}
+
+// measuredResponseTime returns the response time in ms in the comment of a response time failure.
+func measuredResponseTime(failure Failure) int64 {
+	match := tookPattern.FindStringSubmatch(failure.Comment)
+	if failure.Reason != FailureResponseTime || match == nil {
+		return 0
+	}
+	took, _ := strconv.ParseInt(match[1], 10, 64)
+	return took
+}
+
+// runLoad runs a contract repeatedly with the given number of concurrent runs, until it ran repeat times or the
+// duration is over. Zero means no limit, but at least one of both has to be set. Every distinct failure is reported
+// once with the number of runs it occurred in, response times exceeding the maximum once with the slowest of them.
+func runLoad(
+	ctx context.Context,
+	contract serialization.Contract,
+	suite serialization.Suite,
+	severity *Severity,
+	options Options,
+) ContractResult {
+	concurrency := options.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	start := time.Now()
+	var mutex sync.Mutex
+	started := 0
+	next := func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if ctx.Err() != nil || (options.Repeat > 0 && started >= options.Repeat) {
+			return false
+		}
+		if options.Duration > 0 && time.Since(start) >= options.Duration {
+			return false
+		}
+		started++
+		return true
+	}
+
+	stats := &LoadStats{Latencies: make([]int64, 0)}
+	result := NewContractResult(contract.Name)
+	failures := make(map[string]*loadFailure)
+	failureKeys := make([]string, 0)
+	record := func(cr ContractResult) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if cr.Skipped {
+			return
+		}
+
+		result.Name = cr.Name
+		result.StatusCode = cr.StatusCode
+		result.ResponseTime = cr.ResponseTime
+		result.Exchange = cr.Exchange
+		stats.Runs++
+		if cr.Pass(severity) >= ContractFail {
+			stats.Errors++
+		}
+		if cr.StatusCode != 0 {
+			stats.Latencies = append(stats.Latencies, cr.ResponseTime)
+		}
+		for _, failure := range cr.Failures {
+			key := loadFailureKey(failure)
+			grouped, found := failures[key]
+			if !found {
+				grouped = &loadFailure{failure: failure, slowest: measuredResponseTime(failure)}
+				failures[key] = grouped
+				failureKeys = append(failureKeys, key)
+			}
+			grouped.occurrences++
+			if took := measuredResponseTime(failure); took > grouped.slowest {
+				grouped.failure, grouped.slowest = failure, took
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for next() {
+				record(RunContract(ctx, contract, suite, severity))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if stats.Runs == 0 {
+		return NewSkippedResult(contract)
+	}
+	stats.Duration = time.Since(start)
+	sort.Slice(stats.Latencies, func(i, j int) bool {
+		return stats.Latencies[i] < stats.Latencies[j]
+	})
+	for _, key := range failureKeys {
+		failure := failures[key].failure
+		failure.Occurrences, failure.Runs = failures[key].occurrences, stats.Runs
+		result.Failures = append(result.Failures, failure)
+	}
+	result.Load = stats
+	return result
+}
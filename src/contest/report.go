@@ -0,0 +1,156 @@
+package contest
+
+import (
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// reportTemplate renders a self-contained HTML page, the styles and scripts are inlined so the report can be archived
+// as a single file.
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"verdict":        verdictName,
+	"reasons":        resultReasons,
+	"schemaMessages": schemaMessages,
+	"join":           strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Contest report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+h1 { font-size: 1.5em; }
+.filters { margin-bottom: 1em; }
+.filters label { margin-right: 1em; }
+.result { border: 1px solid #ddd; border-radius: 4px; margin-bottom: .5em; padding: .5em; }
+.status { display: inline-block; width: 4em; font-weight: bold; }
+.PASS .status { color: #2a7d2a; }
+.INFO .status { color: #1f6fa8; }
+.WARN .status { color: #b58100; }
+.XFAIL .status { color: #8e24aa; }
+.XPASS .status { color: #b58100; }
+.FAIL .status { color: #c62828; }
+.SKIP .status { color: #888; }
+.tag { background: #eee; border-radius: 3px; font-size: .8em; margin-left: .3em; padding: 0 .3em; }
+.failures { margin: .3em 0 0 4em; }
+pre { background: #f6f6f6; overflow-x: auto; padding: .5em; white-space: pre-wrap; }
+summary { cursor: pointer; }
+</style>
+</head>
+<body>
+<h1>Contest report</h1>
+<p>Generated {{.Generated}}: {{.Report.Passed}}/{{len .Report.Results}} contracts passed{{if .Report.Skipped}}, {{.Report.Skipped}} skipped{{end}}. Final verdict: <b>{{verdict .Report.Verdict false}}</b></p>
+<div class="filters">
+<label>Verdict <select id="verdict"><option value="">all</option><option>PASS</option><option>INFO</option><option>WARN</option><option>XFAIL</option><option>XPASS</option><option>FAIL</option><option>SKIP</option></select></label>
+<label>Reason <select id="reason"><option value="">all</option>{{range .Reasons}}<option>{{.}}</option>{{end}}</select></label>
+<label>Tag <select id="tag"><option value="">all</option>{{range .Tags}}<option>{{.}}</option>{{end}}</select></label>
+</div>
+{{range .Report.Results}}{{$verdict := verdict .Verdict .Skipped}}
+<div class="result {{$verdict}}" data-verdict="{{$verdict}}" data-reasons="{{join (reasons .) " "}}" data-tags="{{join .Tags " "}}">
+<span class="status">{{$verdict}}</span> {{.Name}}{{range .Tags}}<span class="tag">{{.}}</span>{{end}}
+{{if .Failures}}<ul class="failures">{{range .Failures}}{{$messages := schemaMessages .}}
+<li>{{if $messages}}<details><summary>{{.Reason}} ({{len $messages}})</summary><ul>{{range $messages}}<li>{{.}}</li>{{end}}</ul></details>{{else}}{{.}}{{end}}</li>{{end}}
+</ul>{{end}}
+{{with .Exchange}}<details>
+<summary>Request and response</summary>
+<pre>{{.Request.Method}} {{.Request.Url}}
+{{range $key, $value := .Request.Headers}}{{$key}}: {{$value}}
+{{end}}{{if .Request.Body}}
+{{.Request.Body}}{{end}}</pre>
+{{with .Response}}<pre>{{.StatusCode}} ({{.ResponseTime}}ms)
+{{range $key, $value := .Headers}}{{$key}}: {{$value}}
+{{end}}{{if .Body}}
+{{.Body}}{{end}}</pre>{{else}}<p>No response received.</p>{{end}}
+</details>{{end}}
+</div>{{end}}
+<script>
+var filters = ["verdict", "reason", "tag"].map(function (id) { return document.getElementById(id); });
+function applyFilters() {
+	var verdict = filters[0].value, reason = filters[1].value, tag = filters[2].value;
+	document.querySelectorAll(".result").forEach(function (result) {
+		var visible = (!verdict || result.dataset.verdict === verdict) &&
+			(!reason || result.dataset.reasons.split(" ").indexOf(reason) >= 0) &&
+			(!tag || result.dataset.tags.split(" ").indexOf(tag) >= 0);
+		result.style.display = visible ? "" : "none";
+	});
+}
+filters.forEach(function (filter) { filter.addEventListener("change", applyFilters); });
+</script>
+</body>
+</html>
+`))
+
+// WriteHtmlReport writes a self-contained HTML page with the results of the report, which can be filtered by verdict,
+// failure reason and tag. It shows the request sent for every contract and the response received.
+func WriteHtmlReport(w io.Writer, report *Report) error {
+	reasons := make(map[string]bool)
+	tags := make(map[string]bool)
+	for _, result := range report.Results {
+		for _, reason := range resultReasons(result) {
+			reasons[reason] = true
+		}
+		for _, tag := range result.Tags {
+			tags[tag] = true
+		}
+	}
+
+	return reportTemplate.Execute(w, struct {
+		Report    *Report
+		Generated string
+		Reasons   []string
+		Tags      []string
+	}{
+		Report:    report,
+		Generated: time.Now().Format(time.RFC1123),
+		Reasons:   sortedKeys(reasons),
+		Tags:      sortedKeys(tags),
+	})
+}
+
+func verdictName(verdict ContractVerdict, skipped bool) string {
+	if skipped {
+		return "SKIP"
+	} else if verdict >= ContractFail {
+		return "FAIL"
+	} else if verdict >= ContractXPass {
+		return "XPASS"
+	} else if verdict >= ContractXFail {
+		return "XFAIL"
+	} else if verdict >= ContractWarn {
+		return "WARN"
+	} else if verdict >= ContractInfo {
+		return "INFO"
+	}
+	return "PASS"
+}
+
+// resultReasons returns the distinct failure reasons of the result.
+func resultReasons(result ContractResult) []string {
+	reasons := make([]string, 0)
+	for _, failure := range result.Failures {
+		if !containsString(reasons, string(failure.Reason)) {
+			reasons = append(reasons, string(failure.Reason))
+		}
+	}
+	return reasons
+}
+
+// schemaMessages returns the messages of a schema failure. It returns nil for other failures.
+func schemaMessages(failure Failure) []string {
+	if failure.Reason != FailureSchema || failure.Comment == "" {
+		return nil
+	}
+	return failure.messages()
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,210 @@
+package contest
+
+import (
+	"bytes"
+	"context"
+	"contract-testing/src/serialization"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+type RequestResult struct {
+	StatusCode   int
+	Body         []byte
+	ContentType  string
+	ResponseTime int64
+	// RetryAfter is the value of the Retry-After header
+	RetryAfter string
+	Headers    http.Header
+}
+
+// DefaultTimeout is the maximum time of a request, if no timeout is configured.
+const DefaultTimeout = 30 * time.Second
+
+var (
+	clients      = make(map[string]*http.Client)
+	clientsMutex sync.Mutex
+)
+
+// HttpClient returns the client for the given config. Clients are shared between all requests with the same config, so
+// connections can be reused.
+func HttpClient(config serialization.HttpConfig) (*http.Client, error) {
+	// The JSON encoding contains the values of all options, which makes it a suitable key
+	encoded, _ := json.Marshal(config)
+	key := string(encoded)
+
+	clientsMutex.Lock()
+	defer clientsMutex.Unlock()
+
+	if client, found := clients[key]; found {
+		return client, nil
+	}
+
+	client, err := newHttpClient(config)
+	if err != nil {
+		return nil, err
+	}
+	clients[key] = client
+	return client, nil
+}
+
+func newHttpClient(config serialization.HttpConfig) (*http.Client, error) {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	if config.ConnectTimeout != nil {
+		dialer.Timeout = time.Duration(*config.ConnectTimeout) * time.Millisecond
+	}
+
+	tlsConfig, err := newTlsConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	if config.Proxy != "" {
+		proxy, err := url.Parse(config.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %s", err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	if config.Http2 != nil && !*config.Http2 {
+		// A non-nil, empty map disables HTTP/2
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   DefaultTimeout,
+	}
+	if config.Timeout != nil {
+		client.Timeout = time.Duration(*config.Timeout) * time.Millisecond
+	}
+
+	if config.FollowRedirects != nil && !*config.FollowRedirects {
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	} else if config.MaxRedirects != nil {
+		maxRedirects := *config.MaxRedirects
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if len(via) > maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return nil
+		}
+	}
+
+	return client, nil
+}
+
+func newTlsConfig(config serialization.HttpConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+
+	if config.InsecureSkipVerify != nil {
+		tlsConfig.InsecureSkipVerify = *config.InsecureSkipVerify
+	}
+
+	if config.CaFile != "" {
+		pem, err := ioutil.ReadFile(config.CaFile)
+		if err != nil {
+			return nil, err
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", config.CaFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.ClientCert != "" || config.ClientKey != "" {
+		certificate, err := tls.LoadX509KeyPair(config.ClientCert, config.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
+
+func RunRequest(ctx context.Context, client *http.Client, method string, url string, headers map[string]string, body []byte) (*RequestResult, error) {
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(method), url, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	release, err := throttleFrom(ctx).acquire(ctx, req.URL.Host)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	start := time.Now()
+	rres, err := client.Do(req)
+	responseTime := time.Since(start)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if rres.Body != nil {
+		defer func(Body io.ReadCloser) {
+			_ = Body.Close()
+		}(rres.Body)
+	}
+
+	res := RequestResult{
+		StatusCode:   rres.StatusCode,
+		ResponseTime: responseTime.Milliseconds(),
+	}
+
+	responseBody, err := ioutil.ReadAll(rres.Body)
+	if err != nil {
+		return nil, err
+	}
+	res.Body = responseBody
+	res.ContentType = rres.Header.Get("Content-Type")
+	res.RetryAfter = rres.Header.Get("Retry-After")
+	res.Headers = rres.Header
+
+	return &res, nil
+}
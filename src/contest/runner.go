@@ -0,0 +1,266 @@
+// Package contest runs the contracts of a contest suite against an API and validates the responses.
+package contest
+
+import (
+	"context"
+	"contract-testing/src/serialization"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options configure how the contracts of a suite are run.
+type Options struct {
+	// Workers is the number of contracts run concurrently (default: 1)
+	Workers int
+	// BaseUrl replaces the scheme, host and port of the URLs of all contracts, e.g. to run a suite against a test server
+	BaseUrl string
+	// Rate is the maximum number of requests sent per second, 0 for no limit
+	Rate float64
+	// Repeat and Duration enable the load mode, in which every contract is run Repeat times or until Duration is over,
+	// with Concurrency runs at a time (default: 1). The results contain the statistics of the runs.
+	Repeat      int
+	Duration    time.Duration
+	Concurrency int
+	// Shuffle runs the contracts in an order determined by Seed, to expose dependencies between contracts
+	Shuffle bool
+	Seed    int64
+	// OnResult is called with the result of every contract as soon as it has run. Calls are not concurrent.
+	OnResult func(result ContractResult)
+}
+
+// Report contains the results of all contracts in the order of the contracts, regardless of the order in which they
+// were run. Contracts which were not run to completion, because the context was done, are reported as skipped.
+type Report struct {
+	Results []ContractResult
+	// Passed is the number of contracts that passed, possibly with warnings
+	Passed int
+	// Skipped is the number of contracts that were not run to completion
+	Skipped int
+	// KnownFailures and UnexpectedPasses are the numbers of passed contracts with the verdict XFAIL and XPASS
+	KnownFailures    int
+	UnexpectedPasses int
+	// Verdict combines the verdicts of all results
+	Verdict ContractVerdict
+}
+
+// Failed checks if any contract failed. Skipped contracts did not fail.
+func (r Report) Failed() bool {
+	return r.Passed+r.Skipped < len(r.Results)
+}
+
+// CreateContracts returns the contracts of the suite together with the contracts created for the operations of its
+// spec files and for the traffic recorded in its HAR files.
+func CreateContracts(suite serialization.Suite) ([]serialization.Contract, error) {
+	contracts := append([]serialization.Contract{}, suite.Contracts...)
+	for _, specFile := range suite.SpecFiles {
+		specContracts, err := specFile.CreateContracts()
+		if err != nil {
+			return nil, fmt.Errorf("could not create contracts for spec file %s: %w", specFile.Path, err)
+		}
+		contracts = append(contracts, specContracts...)
+	}
+
+	for _, harFile := range suite.HarFiles {
+		harContracts, err := harFile.CreateContracts()
+		if err != nil {
+			return nil, fmt.Errorf("could not create contracts for HAR file %s: %w", harFile.Path, err)
+		}
+		contracts = append(contracts, harContracts...)
+	}
+	return contracts, nil
+}
+
+// Run creates the contracts of the suite with CreateContracts and runs them.
+func Run(ctx context.Context, suite serialization.Suite, options Options) (*Report, error) {
+	contracts, err := CreateContracts(suite)
+	if err != nil {
+		return nil, err
+	}
+	return RunContracts(ctx, contracts, suite, options)
+}
+
+// RunContracts runs the contracts with the headers, HTTP config and credentials of the suite. Once the context is done,
+// requests in flight are cancelled and no further contracts are started. These contracts are reported as skipped and
+// the error of the context is returned together with the report.
+func RunContracts(ctx context.Context, contracts []serialization.Contract, suite serialization.Suite, options Options) (*Report, error) {
+	keys := make([]string, len(contracts))
+	for i, contract := range contracts {
+		keys[i] = contractKey(contract)
+	}
+	if options.BaseUrl != "" {
+		rebased := make([]serialization.Contract, len(contracts))
+		for i, contract := range contracts {
+			if err := rebaseContract(&contract, options.BaseUrl); err != nil {
+				return nil, err
+			}
+			rebased[i] = contract
+		}
+		contracts = rebased
+	}
+
+	workers := options.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	ctx = withThrottle(ctx, newThrottle(options.Rate, suite.MaxConcurrentPerHost))
+
+	order := make([]int, len(contracts))
+	for i := range order {
+		order[i] = i
+	}
+	if options.Shuffle {
+		random := rand.New(rand.NewSource(options.Seed))
+		random.Shuffle(len(order), func(i, j int) {
+			order[i], order[j] = order[j], order[i]
+		})
+	}
+
+	jobs := make(chan job)
+	results := make(chan jobResult)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			worker(ctx, jobs, results, suite, options)
+		}()
+	}
+
+	// Send contracts to workers until all are sent or the context is done
+	go func() {
+		sent := 0
+	contracts:
+		for _, index := range order {
+			select {
+			case jobs <- job{index: index, contract: contracts[index]}:
+				sent++
+			case <-ctx.Done():
+				break contracts
+			}
+		}
+		close(jobs)
+
+		wg.Wait()
+		for _, index := range order[sent:] {
+			results <- jobResult{index: index, result: NewSkippedResult(contracts[index])}
+		}
+		close(results)
+	}()
+
+	report := &Report{Results: make([]ContractResult, len(contracts))}
+	for r := range results {
+		res := r.result
+		res.key = keys[r.index]
+		report.Results[r.index] = res
+		report.Verdict |= res.Verdict
+		if res.Skipped {
+			report.Skipped++
+		} else if res.Verdict < ContractFail {
+			report.Passed++
+			if res.Verdict >= ContractXPass {
+				report.UnexpectedPasses++
+			} else if res.Verdict >= ContractXFail {
+				report.KnownFailures++
+			}
+		}
+		if options.OnResult != nil {
+			options.OnResult(res)
+		}
+	}
+	return report, ctx.Err()
+}
+
+// contractKey identifies a contract across runs by its name, or by its method and URL template if it is unnamed. Unlike
+// the name of its result, the key doesn't depend on parameter values, the base URL or the alternative of anyOf that was
+// reported.
+func contractKey(contract serialization.Contract) string {
+	if contract.Name != "" {
+		return contract.Name
+	}
+	return strings.TrimSpace(contract.Method + " " + contract.Url)
+}
+
+// job is a contract to run and its index in the contracts of the run.
+type job struct {
+	index    int
+	contract serialization.Contract
+}
+
+type jobResult struct {
+	index  int
+	result ContractResult
+}
+
+func worker(
+	ctx context.Context,
+	jobs <-chan job,
+	results chan<- jobResult,
+	suite serialization.Suite,
+	options Options,
+) {
+	for j := range jobs {
+		severity := NewSeverity(suite, j.contract)
+		var res ContractResult
+		latencies := make([]int64, 0)
+		if options.Repeat > 0 || options.Duration > 0 {
+			res = runLoad(ctx, j.contract, suite, severity, options)
+			if res.Load != nil {
+				latencies = res.Load.Latencies
+			}
+		} else {
+			res = RunContract(ctx, j.contract, suite, severity)
+			if res.StatusCode != 0 {
+				latencies = append(latencies, res.ResponseTime)
+			}
+		}
+
+		res.Contract = j.contract.Name
+		res.Tags = j.contract.Tags
+		if !res.Skipped {
+			checkPercentiles(&res, j.contract.Expect.ResponseTime, latencies)
+			for _, known := range severity.expiredKnownFailures(res.Failures) {
+				res.Failures = append(res.Failures, Failure{
+					Reason:      FailureContract,
+					Comment:     fmt.Sprintf("known failure %s expired on %s", known.Ticket, known.Expires),
+					knownExpiry: true,
+				})
+			}
+			res.Verdict = res.Pass(severity)
+			res.Failures = severity.withoutIgnored(res.Failures)
+			severity.markKnown(res.Failures)
+		}
+		results <- jobResult{index: j.index, result: res}
+	}
+}
+
+// rebaseContract replaces the scheme and host of the URLs of the contract and its subcontracts with baseUrl. Relative
+// URLs are appended to baseUrl.
+func rebaseContract(contract *serialization.Contract, baseUrl string) error {
+	if contract.Url != "" {
+		u, err := url.Parse(contract.Url)
+		if err != nil {
+			return err
+		}
+		// The URL is not reassembled from u, which would escape the braces of path parameters
+		path := strings.TrimPrefix(contract.Url, u.Scheme+"://"+u.Host)
+		contract.Url = strings.TrimSuffix(baseUrl, "/") + path
+	}
+
+	if contract.AnyOf == nil {
+		return nil
+	}
+	anyOf := make([]*serialization.Contract, len(contract.AnyOf))
+	for i, subcontract := range contract.AnyOf {
+		rebased := *subcontract
+		if err := rebaseContract(&rebased, baseUrl); err != nil {
+			return err
+		}
+		anyOf[i] = &rebased
+	}
+	contract.AnyOf = anyOf
+	return nil
+}
@@ -0,0 +1,225 @@
+package contest
+
+import (
+	"contract-testing/src/serialization"
+	"strings"
+	"time"
+)
+
+// Severity resolves the levels of the failures of a contract from the severity rules of the suite and the contract,
+// and which of them are known failures. A nil Severity treats every failure as error.
+type Severity struct {
+	rules serialization.Severity
+	tags  []string
+
+	// contract is the name of the contract the known failures are matched with
+	contract      string
+	knownFailures serialization.KnownFailures
+	now           time.Time
+}
+
+// NewSeverity creates the Severity of a contract. The rules of the contract, which include the rules of its spec file
+// operation, take precedence over the rules of the suite.
+func NewSeverity(suite serialization.Suite, contract serialization.Contract) *Severity {
+	severity := &Severity{
+		rules:         suite.Severity,
+		tags:          contract.Tags,
+		contract:      contract.Name,
+		knownFailures: suite.KnownFailures,
+		now:           time.Now(),
+	}
+	return severity.forContract(contract)
+}
+
+// forContract adds the rules of a contract, e.g. of a subcontract, to the rules.
+func (s *Severity) forContract(contract serialization.Contract) *Severity {
+	if len(contract.Severity) == 0 {
+		return s
+	}
+
+	severity := &Severity{rules: make(serialization.Severity, 0), tags: contract.Tags, contract: contract.Name}
+	if s != nil {
+		copied := *s
+		severity = &copied
+		severity.rules = append(make(serialization.Severity, 0), s.rules...)
+	}
+	severity.rules = append(severity.rules, contract.Severity...)
+	return severity
+}
+
+// Level returns the level of the last rule matching the failure, error if no rule matches. Rules are matched with every
+// message of a failure with several messages, and the highest level of them is returned. Debug output is ignored.
+func (s *Severity) Level(failure Failure) serialization.SeverityLevel {
+	if failure.Reason == FailureDebug {
+		return serialization.SeverityIgnore
+	}
+
+	level := serialization.SeverityIgnore
+	for _, message := range failure.messages() {
+		if messageLevel := s.messageLevel(failure.Reason, message); severityRank(messageLevel) > severityRank(level) {
+			level = messageLevel
+		}
+	}
+	return level
+}
+
+// messageLevel returns the level of the last rule matching a message of a failure, error if no rule matches.
+func (s *Severity) messageLevel(reason FailureReason, message string) serialization.SeverityLevel {
+	level := serialization.SeverityError
+	if s == nil {
+		return level
+	}
+	for _, rule := range s.rules {
+		if rule.Matches(string(reason), message, s.tags) {
+			level = rule.Level
+		}
+	}
+	return level
+}
+
+// severityRank orders the levels from ignore to error.
+func severityRank(level serialization.SeverityLevel) int {
+	switch level {
+	case serialization.SeverityInfo:
+		return 1
+	case serialization.SeverityWarn:
+		return 2
+	case serialization.SeverityError:
+		return 3
+	}
+	return 0
+}
+
+// withoutIgnored returns the failures without those which are ignored, and without the ignored messages of failures
+// with several messages. Debug output is kept.
+func (s *Severity) withoutIgnored(failures []Failure) []Failure {
+	kept := make([]Failure, 0, len(failures))
+	for _, failure := range failures {
+		if failure.Reason == FailureDebug {
+			kept = append(kept, failure)
+			continue
+		}
+		if s.Level(failure) == serialization.SeverityIgnore {
+			continue
+		}
+		if len(failure.Messages) > 0 {
+			messages := make([]string, 0, len(failure.Messages))
+			for _, message := range failure.Messages {
+				if s.messageLevel(failure.Reason, message) != serialization.SeverityIgnore {
+					messages = append(messages, message)
+				}
+			}
+			withMessages := newFailureWithMessages(failure.Reason, messages)
+			failure.Comment, failure.Messages = withMessages.Comment, withMessages.Messages
+		}
+		kept = append(kept, failure)
+	}
+	return kept
+}
+
+// knownTicket checks if every message of the failure whose level is error matches a known failure, and returns the
+// tickets of the known failures. Expired known failures don't match, and the failures reporting them are never known.
+func (s *Severity) knownTicket(failure Failure) (string, bool) {
+	if s == nil || failure.knownExpiry {
+		return "", false
+	}
+
+	tickets := make([]string, 0, 1)
+	for _, message := range failure.messages() {
+		if s.messageLevel(failure.Reason, message) != serialization.SeverityError {
+			continue
+		}
+		known := s.knownMessage(failure.Reason, message)
+		if known == nil {
+			return "", false
+		}
+		if !containsString(tickets, known.Ticket) {
+			tickets = append(tickets, known.Ticket)
+		}
+	}
+	return strings.Join(tickets, ", "), len(tickets) > 0
+}
+
+// knownMessage returns the known failure matching a message of a failure, nil if none matches. Expired known failures
+// don't match.
+func (s *Severity) knownMessage(reason FailureReason, message string) *serialization.KnownFailure {
+	for i, known := range s.knownFailures {
+		if !known.Expired(s.now) && known.Matches(s.contract, string(reason), message) {
+			return &s.knownFailures[i]
+		}
+	}
+	return nil
+}
+
+// matchesKnown checks if the known failure matches one of the messages of the failure.
+func (s *Severity) matchesKnown(known serialization.KnownFailure, failure Failure) bool {
+	if failure.knownExpiry {
+		return false
+	}
+	for _, message := range failure.messages() {
+		if known.Matches(s.contract, string(failure.Reason), message) {
+			return true
+		}
+	}
+	return false
+}
+
+// missingKnownFailure checks if a known failure, which refers to the contract by name and has not expired, matches
+// none of the failures.
+func (s *Severity) missingKnownFailure(failures []Failure) bool {
+	if s == nil {
+		return false
+	}
+
+known:
+	for _, known := range s.knownFailures {
+		if known.Contract == "" || !known.AppliesTo(s.contract) || known.Expired(s.now) {
+			continue
+		}
+		for _, failure := range failures {
+			if s.matchesKnown(known, failure) {
+				continue known
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// expiredKnownFailures returns the expired known failures which refer to the contract by name or match one of the
+// failures.
+func (s *Severity) expiredKnownFailures(failures []Failure) []serialization.KnownFailure {
+	expired := make([]serialization.KnownFailure, 0)
+	if s == nil {
+		return expired
+	}
+
+	for _, known := range s.knownFailures {
+		if !known.Expired(s.now) {
+			continue
+		}
+		if known.Contract != "" && known.AppliesTo(s.contract) {
+			expired = append(expired, known)
+			continue
+		}
+		for _, failure := range failures {
+			if s.matchesKnown(known, failure) {
+				expired = append(expired, known)
+				break
+			}
+		}
+	}
+	return expired
+}
+
+// markKnown sets the tickets of the failures which are known.
+func (s *Severity) markKnown(failures []Failure) {
+	for i, failure := range failures {
+		if s.Level(failure) != serialization.SeverityError {
+			continue
+		}
+		if ticket, known := s.knownTicket(failure); known {
+			failures[i].Ticket = ticket
+		}
+	}
+}
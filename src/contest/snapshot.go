@@ -0,0 +1,196 @@
+package contest
+
+import (
+	"bytes"
+	"contract-testing/src/validation"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// snapshotMaxDifferences is the maximum number of differences listed in the failure comment.
+const snapshotMaxDifferences = 10
+
+// checkSnapshot compares a response body with the snapshot file. JSON bodies are compared structurally, values at the
+// ignored paths are skipped. Other bodies have to be equal. If update is true, the snapshot file is rewritten with the
+// body instead.
+func checkSnapshot(data []byte, path string, ignore []string, update bool) (FailureReason, string) {
+	actual, err := validation.JsonUnmarshal(data)
+	isJson := err == nil
+
+	if update {
+		if err = writeSnapshot(path, data, actual, isJson); err != nil {
+			return FailureIO, err.Error()
+		}
+		return "", ""
+	}
+
+	stored, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return FailureSnapshot, fmt.Sprintf("snapshot %s does not exist, run with --update-snapshots to create it", path)
+	} else if err != nil {
+		return FailureIO, err.Error()
+	}
+
+	if !isJson {
+		if !bytes.Equal(data, stored) {
+			return FailureSnapshot, "body differs from snapshot " + path
+		}
+		return "", ""
+	}
+
+	expected, err := validation.JsonUnmarshal(stored)
+	if err != nil {
+		return FailureSnapshot, fmt.Sprintf("snapshot %s is not valid JSON: %s", path, err)
+	}
+
+	differences := make([]string, 0)
+	diffJson(expected, actual, "", ignore, &differences)
+	if len(differences) == 0 {
+		return "", ""
+	}
+	if len(differences) > snapshotMaxDifferences {
+		more := len(differences) - snapshotMaxDifferences
+		differences = append(differences[:snapshotMaxDifferences], fmt.Sprintf("and %d more", more))
+	}
+	return FailureSnapshot, strings.Join(differences, ", ")
+}
+
+// writeSnapshot writes the body to the snapshot file. JSON bodies are indented, so the snapshot can be reviewed.
+func writeSnapshot(path string, data []byte, value interface{}, isJson bool) error {
+	if isJson {
+		indented, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return err
+		}
+		data = append(indented, '\n')
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// diffJson adds a description of every difference between the expected and the actual value to differences. Paths
+// have the same form as in JsonPathValue.
+func diffJson(expected interface{}, actual interface{}, path string, ignore []string, differences *[]string) {
+	if snapshotIgnored(path, ignore) {
+		return
+	}
+
+	switch e := expected.(type) {
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok {
+			break
+		}
+		for _, key := range unionKeys(e, a) {
+			childPath := joinJsonPath(path, key)
+			expectedChild, inExpected := e[key]
+			actualChild, inActual := a[key]
+			if snapshotIgnored(childPath, ignore) {
+				continue
+			}
+			if !inActual {
+				*differences = append(*differences, "missing "+childPath)
+			} else if !inExpected {
+				*differences = append(*differences, "unexpected "+childPath)
+			} else {
+				diffJson(expectedChild, actualChild, childPath, ignore, differences)
+			}
+		}
+		return
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < len(e) || i < len(a); i++ {
+			childPath := fmt.Sprintf("%s[%d]", path, i)
+			if snapshotIgnored(childPath, ignore) {
+				continue
+			}
+			if i >= len(a) {
+				*differences = append(*differences, "missing "+childPath)
+			} else if i >= len(e) {
+				*differences = append(*differences, "unexpected "+childPath)
+			} else {
+				diffJson(e[i], a[i], childPath, ignore, differences)
+			}
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		*differences = append(*differences, fmt.Sprintf("%s is %s not %s", displayJsonPath(path), jsonString(actual), jsonString(expected)))
+	}
+}
+
+// snapshotIgnored checks if the path matches one of the ignored paths. A * in an ignored path matches any property
+// name or array index.
+func snapshotIgnored(path string, ignore []string) bool {
+	segments := jsonPathSegments(path)
+outer:
+	for _, pattern := range ignore {
+		patternSegments := jsonPathSegments(pattern)
+		if len(patternSegments) != len(segments) {
+			continue
+		}
+		for i, segment := range patternSegments {
+			if segment != "*" && segment != segments[i] {
+				continue outer
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func jsonPathSegments(path string) []string {
+	path = strings.ReplaceAll(validation.JsonPathIndexPattern.ReplaceAllString(path, ".$1"), "[*]", ".*")
+	return strings.FieldsFunc(path, func(r rune) bool {
+		return r == '.'
+	})
+}
+
+func joinJsonPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func displayJsonPath(path string) string {
+	if path == "" {
+		return "body"
+	}
+	return path
+}
+
+func jsonString(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+func unionKeys(a map[string]interface{}, b map[string]interface{}) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, found := a[key]; !found {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
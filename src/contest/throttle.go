@@ -0,0 +1,138 @@
+package contest
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxRetryAfter is the longest Retry-After of a 429 response that is waited for. Responses asking to wait longer are
+// checked like any other response.
+const maxRetryAfter = time.Minute
+
+// maxRateLimitRetries is the number of times a request is sent again after 429 responses.
+const maxRateLimitRetries = 5
+
+// throttle limits the rate of all requests and the number of concurrent requests per host, and pauses requests to hosts
+// which answered with status 429. A nil throttle doesn't limit requests, e.g. of contracts run without RunContracts.
+type throttle struct {
+	// interval is the minimum time between the start of two requests, 0 for no limit
+	interval   time.Duration
+	maxPerHost int
+
+	mutex sync.Mutex
+	next  time.Time
+	hosts map[string]chan struct{}
+	// pausedUntil maps hosts to the time until which they asked to not receive requests
+	pausedUntil map[string]time.Time
+}
+
+// newThrottle creates a throttle for the rate in requests per second and the maximum of concurrent requests per host.
+// Neither is limited if it is 0, but hosts are still paused when they ask to.
+func newThrottle(rate float64, maxPerHost int) *throttle {
+	t := &throttle{
+		maxPerHost:  maxPerHost,
+		hosts:       make(map[string]chan struct{}),
+		pausedUntil: make(map[string]time.Time),
+	}
+	if rate > 0 {
+		t.interval = time.Duration(float64(time.Second) / rate)
+	}
+	return t
+}
+
+type throttleKey struct{}
+
+func withThrottle(ctx context.Context, t *throttle) context.Context {
+	return context.WithValue(ctx, throttleKey{}, t)
+}
+
+func throttleFrom(ctx context.Context) *throttle {
+	t, _ := ctx.Value(throttleKey{}).(*throttle)
+	return t
+}
+
+// acquire waits until a request may be sent to the host. The returned function has to be called once the request is
+// done. An error is returned if the context is done before.
+func (t *throttle) acquire(ctx context.Context, host string) (func(), error) {
+	if t == nil {
+		return func() {}, nil
+	}
+
+	release := func() {}
+	if t.maxPerHost > 0 {
+		slots := t.hostSlots(host)
+		select {
+		case slots <- struct{}{}:
+			release = func() { <-slots }
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	t.mutex.Lock()
+	start := t.pausedUntil[host]
+	if t.interval > 0 {
+		slot := time.Now()
+		if t.next.After(slot) {
+			slot = t.next
+		}
+		t.next = slot.Add(t.interval)
+		if slot.After(start) {
+			start = slot
+		}
+	}
+	t.mutex.Unlock()
+
+	if !sleep(ctx, time.Until(start)) {
+		release()
+		return nil, ctx.Err()
+	}
+	return release, nil
+}
+
+func (t *throttle) hostSlots(host string) chan struct{} {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	slots, found := t.hosts[host]
+	if !found {
+		slots = make(chan struct{}, t.maxPerHost)
+		t.hosts[host] = slots
+	}
+	return slots
+}
+
+// pause delays all further requests to the host by the duration.
+func (t *throttle) pause(host string, duration time.Duration) {
+	if t == nil {
+		return
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if until := time.Now().Add(duration); until.After(t.pausedUntil[host]) {
+		t.pausedUntil[host] = until
+	}
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is either a number of seconds or an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
@@ -0,0 +1,32 @@
+package contest
+
+import (
+	"contract-testing/src/serialization"
+	"contract-testing/src/validation"
+	"sort"
+)
+
+// checkParameterSchemas validates the parameter values of a contract against the schemas of the parameters. It returns
+// a message for every invalid parameter.
+func checkParameterSchemas(contract serialization.Contract) []string {
+	keys := make([]string, 0, len(contract.ParameterSchemas))
+	for key := range contract.ParameterSchemas {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	messages := make([]string, 0)
+	for _, key := range keys {
+		value, found := contract.Parameters[key]
+		if !found {
+			continue
+		}
+
+		schema := *contract.ParameterSchemas[key]
+		msgs := make([]string, 0)
+		if !validation.CheckSchema(schema, validation.ParseParameterValue(value, schema), "parameter "+key, &msgs) {
+			messages = append(messages, msgs...)
+		}
+	}
+	return messages
+}
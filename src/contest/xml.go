@@ -0,0 +1,238 @@
+package contest
+
+import (
+	"bytes"
+	"contract-testing/src/serialization/openapi"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const xsiNamespace = "http://www.w3.org/2001/XMLSchema-instance"
+
+// xmlNode is an element of a parsed XML document.
+type xmlNode struct {
+	Name     xml.Name
+	Attrs    []xml.Attr
+	Children []*xmlNode
+	Text     string
+}
+
+// parseXml parses an XML document into a tree of xmlNodes and returns the root element.
+func parseXml(data []byte) (*xmlNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	var root *xmlNode
+	stack := make([]*xmlNode, 0)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{Name: t.Name, Attrs: t.Attr}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, node)
+			} else if root == nil {
+				root = node
+			} else {
+				return nil, errors.New("more than one root element")
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			node := stack[len(stack)-1]
+			node.Text = strings.TrimSpace(node.Text)
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].Text += string(t)
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, errors.New("no root element")
+	}
+	return root, nil
+}
+
+// attr returns the value of the attribute with the given local name. If namespace is not empty, the namespace of the
+// attribute has to match as well.
+func (n *xmlNode) attr(name string, namespace string) (string, bool) {
+	for _, attr := range n.Attrs {
+		if attr.Name.Local == name && (namespace == "" || attr.Name.Space == namespace) {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// children returns all child elements with the given local name. If namespace is not empty, the namespace of the
+// elements has to match as well.
+func (n *xmlNode) children(name string, namespace string) []*xmlNode {
+	children := make([]*xmlNode, 0)
+	for _, child := range n.Children {
+		if child.Name.Local == name && (namespace == "" || child.Name.Space == namespace) {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+// XmlUnmarshal parses an XML document and converts it into the same kind of values JsonUnmarshal returns, such that it
+// can be validated using CheckSchema. The OpenAPI xml objects of the schema describe how elements and attributes map
+// onto the properties of the schema. It returns the converted value and the local name of the root element.
+func XmlUnmarshal(data []byte, schema *openapi.Schema) (interface{}, string, error) {
+	root, err := parseXml(data)
+	if err != nil {
+		return nil, "", err
+	}
+	return xmlValue(root, schema), root.Name.Local, nil
+}
+
+// xmlName returns the name of the element or attribute for the property with the given name.
+func xmlName(name string, schema *openapi.Schema) string {
+	if schema != nil && schema.Xml != nil && schema.Xml.Name != "" {
+		return schema.Xml.Name
+	}
+	return name
+}
+
+func xmlNamespace(schema *openapi.Schema) string {
+	if schema != nil && schema.Xml != nil {
+		return schema.Xml.Namespace
+	}
+	return ""
+}
+
+func xmlValue(node *xmlNode, schema *openapi.Schema) interface{} {
+	if nilValue, ok := node.attr("nil", xsiNamespace); ok && nilValue == "true" {
+		return nil
+	}
+
+	if schema == nil {
+		return xmlGenericValue(node)
+	}
+
+	switch {
+	case schema.Type == openapi.SchemaTypeObject || (schema.Type == "" && len(schema.Properties) > 0):
+		return xmlObjectValue(node, schema)
+	case schema.Type == openapi.SchemaTypeArray:
+		return xmlArrayValue(node, schema.Items, "")
+	case schema.Type == "":
+		return xmlGenericValue(node)
+	}
+	return xmlScalarValue(node.Text, schema)
+}
+
+func xmlObjectValue(node *xmlNode, schema *openapi.Schema) map[string]interface{} {
+	object := make(map[string]interface{})
+	mapped := make(map[*xmlNode]bool)
+
+	for name, property := range schema.Properties {
+		elementName := xmlName(name, property)
+		namespace := xmlNamespace(property)
+
+		if property.Xml != nil && property.Xml.Attribute {
+			if value, found := node.attr(elementName, namespace); found {
+				object[name] = xmlScalarValue(value, property)
+			}
+			continue
+		}
+
+		if property.Type == openapi.SchemaTypeArray && (property.Xml == nil || !property.Xml.Wrapped) {
+			// The items of an unwrapped array are siblings named after the items or the property itself
+			items := node.children(xmlName(elementName, property.Items), xmlNamespace(property.Items))
+			if len(items) == 0 {
+				continue
+			}
+			values := make([]interface{}, len(items))
+			for i, item := range items {
+				mapped[item] = true
+				values[i] = xmlValue(item, property.Items)
+			}
+			object[name] = values
+			continue
+		}
+
+		children := node.children(elementName, namespace)
+		if len(children) == 0 {
+			continue
+		}
+		mapped[children[0]] = true
+		object[name] = xmlValue(children[0], property)
+	}
+
+	// Keep elements not mapped to a property, so they can be validated against additionalProperties
+	for _, child := range node.Children {
+		if _, found := object[child.Name.Local]; !mapped[child] && !found {
+			object[child.Name.Local] = xmlGenericValue(child)
+		}
+	}
+	return object
+}
+
+// xmlArrayValue converts the child elements of a wrapper element into an array. If itemName is empty, all child
+// elements are items.
+func xmlArrayValue(node *xmlNode, items *openapi.Schema, itemName string) []interface{} {
+	if items != nil && items.Xml != nil && items.Xml.Name != "" {
+		itemName = items.Xml.Name
+	}
+
+	values := make([]interface{}, 0, len(node.Children))
+	for _, child := range node.Children {
+		if itemName == "" || child.Name.Local == itemName {
+			values = append(values, xmlValue(child, items))
+		}
+	}
+	return values
+}
+
+// xmlScalarValue converts text into the type of schema. Text that can't be converted is returned as string, such that
+// CheckSchema reports the mismatch.
+func xmlScalarValue(text string, schema *openapi.Schema) interface{} {
+	switch schema.Type {
+	case openapi.SchemaTypeInteger, openapi.SchemaTypeNumber:
+		if parsed, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return parsed
+		}
+		if parsed, err := strconv.ParseFloat(text, 64); err == nil {
+			return parsed
+		}
+	case openapi.SchemaTypeBoolean:
+		if parsed, err := strconv.ParseBool(text); err == nil {
+			return parsed
+		}
+	}
+	return text
+}
+
+// xmlGenericValue converts an element without a schema. Elements without children are converted to their text,
+// others to an object of their children. Repeated children are collected in an array.
+func xmlGenericValue(node *xmlNode) interface{} {
+	if len(node.Children) == 0 {
+		return node.Text
+	}
+
+	object := make(map[string]interface{})
+	for _, child := range node.Children {
+		value := xmlGenericValue(child)
+		switch existing := object[child.Name.Local].(type) {
+		case nil:
+			object[child.Name.Local] = value
+		case []interface{}:
+			object[child.Name.Local] = append(existing, value)
+		default:
+			object[child.Name.Local] = []interface{}{existing, value}
+		}
+	}
+	return object
+}
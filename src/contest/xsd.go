@@ -0,0 +1,793 @@
+package contest
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const xsdNamespace = "http://www.w3.org/2001/XMLSchema"
+
+// xsdComponents are the components of XML Schema supported by xsdSchema. Schemas with other components, e.g. lists,
+// unions, groups or imports, are rejected when they are loaded.
+var xsdComponents = map[string]bool{
+	"element": true, "complexType": true, "simpleType": true, "annotation": true,
+	"sequence": true, "choice": true, "all": true, "any": true, "attribute": true,
+	"simpleContent": true, "complexContent": true, "extension": true, "restriction": true,
+	"enumeration": true, "pattern": true, "length": true, "minLength": true, "maxLength": true,
+	"minInclusive": true, "maxInclusive": true, "minExclusive": true, "maxExclusive": true, "whiteSpace": true,
+}
+
+// xsdBuiltinTypes are the built-in types of XML Schema supported by checkBuiltinType.
+var xsdBuiltinTypes = map[string]bool{
+	"anyType": true, "anySimpleType": true, "string": true, "normalizedString": true, "token": true,
+	"language": true, "Name": true, "NCName": true, "ID": true, "IDREF": true, "NMTOKEN": true,
+	"boolean": true, "decimal": true, "float": true, "double": true,
+	"integer": true, "nonNegativeInteger": true, "positiveInteger": true, "nonPositiveInteger": true,
+	"negativeInteger": true, "long": true, "int": true, "short": true, "byte": true,
+	"unsignedLong": true, "unsignedInt": true, "unsignedShort": true, "unsignedByte": true,
+	"date": true, "dateTime": true, "time": true, "anyURI": true, "base64Binary": true, "hexBinary": true,
+}
+
+// xsdIntegerRanges are the minimum and maximum values of the integer types, an empty string means no limit.
+var xsdIntegerRanges = map[string][2]string{
+	"integer":            {"", ""},
+	"nonNegativeInteger": {"0", ""},
+	"positiveInteger":    {"1", ""},
+	"nonPositiveInteger": {"", "0"},
+	"negativeInteger":    {"", "-1"},
+	"long":               {"-9223372036854775808", "9223372036854775807"},
+	"int":                {"-2147483648", "2147483647"},
+	"short":              {"-32768", "32767"},
+	"byte":               {"-128", "127"},
+	"unsignedLong":       {"0", "18446744073709551615"},
+	"unsignedInt":        {"0", "4294967295"},
+	"unsignedShort":      {"0", "65535"},
+	"unsignedByte":       {"0", "255"},
+}
+
+var (
+	xsdDecimalPattern  = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)$`)
+	xsdDatePattern     = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})(Z|[+-]\d{2}:\d{2})?$`)
+	xsdTimePattern     = regexp.MustCompile(`^(\d{2}:\d{2}:\d{2})(\.\d+)?(Z|[+-]\d{2}:\d{2})?$`)
+	xsdDateTimePattern = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})T(\d{2}:\d{2}:\d{2})(\.\d+)?(Z|[+-]\d{2}:\d{2})?$`)
+)
+
+// maxDerivationDepth limits how many base types of a complex type are resolved, so cyclic derivations terminate.
+const maxDerivationDepth = 32
+
+// xsdSchema validates XML documents against a subset of XML Schema: global and local element declarations, named and
+// anonymous complex and simple types, sequence, choice and all with minOccurs and maxOccurs, attributes, simple and
+// complex content extensions and restrictions, restrictions of the built-in types with enumeration, length, pattern
+// and range facets. Namespaces of elements are ignored. Unsupported components are rejected when the schema is loaded.
+type xsdSchema struct {
+	elements     map[string]*xmlNode
+	complexTypes map[string]*xmlNode
+	simpleTypes  map[string]*xmlNode
+	// prefixes maps the namespace prefixes declared in the schema to their namespaces
+	prefixes map[string]string
+	// patterns maps the first pattern facet of a restriction to all of its pattern facets combined into one alternation
+	patterns map[*xmlNode]*regexp.Regexp
+}
+
+func loadXsd(path string) (*xsdSchema, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := parseXsd(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return schema, nil
+}
+
+// parseXsd parses an XML schema and checks that it only uses supported components and types.
+func parseXsd(content []byte) (*xsdSchema, error) {
+	root, err := parseXml(content)
+	if err != nil {
+		return nil, err
+	}
+	if root.Name.Local != "schema" || root.Name.Space != xsdNamespace {
+		return nil, fmt.Errorf("not an XML schema")
+	}
+
+	schema := &xsdSchema{
+		elements:     make(map[string]*xmlNode),
+		complexTypes: make(map[string]*xmlNode),
+		simpleTypes:  make(map[string]*xmlNode),
+		prefixes:     make(map[string]string),
+		patterns:     make(map[*xmlNode]*regexp.Regexp),
+	}
+	for _, attr := range root.Attrs {
+		if attr.Name.Space == "xmlns" {
+			schema.prefixes[attr.Name.Local] = attr.Value
+		} else if attr.Name.Space == "" && attr.Name.Local == "xmlns" {
+			schema.prefixes[""] = attr.Value
+		}
+	}
+	for _, child := range root.Children {
+		name, _ := child.attr("name", "")
+		switch child.Name.Local {
+		case "element":
+			schema.elements[name] = child
+		case "complexType":
+			schema.complexTypes[name] = child
+		case "simpleType":
+			schema.simpleTypes[name] = child
+		}
+	}
+	if err := schema.checkSupported(root); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// checkSupported checks that the descendants of the node only use supported components and reference known types and
+// elements, so documents are never accepted because of a construct the validator ignores.
+func (s *xsdSchema) checkSupported(node *xmlNode) error {
+	for _, child := range node.Children {
+		if child.Name.Local == "annotation" {
+			continue
+		}
+		if !xsdComponents[child.Name.Local] {
+			return fmt.Errorf("unsupported XML schema component %s", child.Name.Local)
+		}
+
+		for _, attr := range []string{"type", "base"} {
+			if typeName, found := child.attr(attr, ""); found {
+				if err := s.checkTypeReference(typeName); err != nil {
+					return err
+				}
+			}
+		}
+		if child.Name.Local == "restriction" {
+			if err := s.compilePatterns(child); err != nil {
+				return err
+			}
+		}
+		if ref, found := child.attr("ref", ""); found {
+			if child.Name.Local != "element" {
+				return fmt.Errorf("unsupported reference to %s %s", child.Name.Local, ref)
+			}
+			if s.elements[localName(ref)] == nil {
+				return fmt.Errorf("reference to undeclared element %s", ref)
+			}
+		}
+
+		if err := s.checkSupported(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compilePatterns combines the pattern facets of a restriction, of which a value has to match one, into a regular
+// expression. Patterns using XML schema syntax which Go doesn't support, e.g. \i, \c or \p{IsBasicLatin}, are
+// rejected.
+func (s *xsdSchema) compilePatterns(restriction *xmlNode) error {
+	facets := restriction.children("pattern", "")
+	alternatives := make([]string, 0, len(facets))
+	for _, facet := range facets {
+		value, _ := facet.attr("value", "")
+		if _, err := regexp.Compile(value); err != nil {
+			return fmt.Errorf("unsupported pattern %s: %w", value, err)
+		}
+		alternatives = append(alternatives, "(?:"+value+")")
+	}
+	if len(facets) > 0 {
+		s.patterns[facets[0]] = regexp.MustCompile("^(?:" + strings.Join(alternatives, "|") + ")$")
+	}
+	return nil
+}
+
+func (s *xsdSchema) checkTypeReference(typeName string) error {
+	if builtin, found := s.builtinType(typeName); found {
+		if !xsdBuiltinTypes[builtin] {
+			return fmt.Errorf("unsupported built-in type %s", typeName)
+		}
+		return nil
+	}
+	if s.complexTypes[localName(typeName)] == nil && s.simpleTypes[localName(typeName)] == nil {
+		return fmt.Errorf("unknown type %s", typeName)
+	}
+	return nil
+}
+
+// Validate validates an XML document against the schema and returns whether it is valid. The reasons why it is not
+// valid are added to messages.
+func (s *xsdSchema) Validate(root *xmlNode, messages *[]string) bool {
+	declaration, found := s.elements[root.Name.Local]
+	if !found {
+		*messages = append(*messages, fmt.Sprintf("root element %s is not declared", root.Name.Local))
+		return false
+	}
+	return s.validateElement(root, declaration, root.Name.Local, messages)
+}
+
+// builtinType returns the name of the built-in type if typeName refers to one.
+func (s *xsdSchema) builtinType(typeName string) (string, bool) {
+	parts := strings.SplitN(typeName, ":", 2)
+	if len(parts) == 2 && s.prefixes[parts[0]] == xsdNamespace {
+		return parts[1], true
+	}
+	if len(parts) == 1 && s.prefixes[""] == xsdNamespace {
+		return parts[0], true
+	}
+	return "", false
+}
+
+func localName(name string) string {
+	parts := strings.Split(name, ":")
+	return parts[len(parts)-1]
+}
+
+func (s *xsdSchema) validateElement(node *xmlNode, declaration *xmlNode, path string, messages *[]string) bool {
+	if ref, found := declaration.attr("ref", ""); found {
+		if declaration = s.elements[localName(ref)]; declaration == nil {
+			*messages = append(*messages, fmt.Sprintf("%s references undeclared element %s", path, ref))
+			return false
+		}
+	}
+
+	if typeName, found := declaration.attr("type", ""); found {
+		return s.validateType(node, typeName, path, messages)
+	}
+
+	for _, child := range declaration.Children {
+		switch child.Name.Local {
+		case "complexType":
+			return s.validateComplexType(node, child, path, messages)
+		case "simpleType":
+			return s.validateSimpleElement(node, path, messages, func(value string) []string {
+				return s.checkSimpleType(value, child)
+			})
+		}
+	}
+
+	// An element without a type can have any content
+	return true
+}
+
+func (s *xsdSchema) validateType(node *xmlNode, typeName string, path string, messages *[]string) bool {
+	if builtin, found := s.builtinType(typeName); found {
+		if builtin == "anyType" {
+			return true
+		}
+		return s.validateSimpleElement(node, path, messages, func(value string) []string {
+			return checkBuiltinType(value, builtin)
+		})
+	}
+
+	if complexType, found := s.complexTypes[localName(typeName)]; found {
+		return s.validateComplexType(node, complexType, path, messages)
+	}
+	if simpleType, found := s.simpleTypes[localName(typeName)]; found {
+		return s.validateSimpleElement(node, path, messages, func(value string) []string {
+			return s.checkSimpleType(value, simpleType)
+		})
+	}
+
+	*messages = append(*messages, fmt.Sprintf("%s has unknown type %s", path, typeName))
+	return false
+}
+
+// validateSimpleElement validates an element that may only contain text. check returns the problems with the text.
+func (s *xsdSchema) validateSimpleElement(node *xmlNode, path string, messages *[]string, check func(string) []string) bool {
+	if len(node.Children) > 0 {
+		*messages = append(*messages, fmt.Sprintf("%s must not have child elements", path))
+		return false
+	}
+
+	problems := check(node.Text)
+	for _, problem := range problems {
+		*messages = append(*messages, fmt.Sprintf("%s %s", path, problem))
+	}
+	return len(problems) == 0
+}
+
+func (s *xsdSchema) validateComplexType(node *xmlNode, complexType *xmlNode, path string, messages *[]string) bool {
+	model := s.contentModel(complexType, 0)
+
+	valid := true
+	if model.simple {
+		valid = s.validateSimpleElement(node, path, messages, func(value string) []string {
+			problems := make([]string, 0)
+			for _, check := range model.checks {
+				problems = append(problems, check(value)...)
+			}
+			return problems
+		})
+	}
+	valid = s.validateAttributes(node, model.attributes, path, messages) && valid
+
+	if model.content == nil {
+		if len(node.Children) > 0 && !model.simple {
+			*messages = append(*messages, fmt.Sprintf("%s must not have child elements", path))
+			return false
+		}
+		return valid
+	}
+
+	index, contentValid := s.matchParticle(node.Children, 0, model.content, path, messages)
+	valid = contentValid && valid
+	for ; index < len(node.Children); index++ {
+		valid = false
+		*messages = append(*messages, fmt.Sprintf("unexpected element %s.%s", path, node.Children[index].Name.Local))
+	}
+	return valid
+}
+
+// contentModel is a complex type with its base types resolved.
+type contentModel struct {
+	attributes []*xmlNode
+	// content is the particle matching the child elements, nil if there are none
+	content *xmlNode
+	// simple is true for simple content, whose text is validated by checks
+	simple bool
+	checks []func(string) []string
+}
+
+// contentModel resolves the attributes and content of a complex type, including those of the types it is derived
+// from with complexContent or simpleContent.
+func (s *xsdSchema) contentModel(complexType *xmlNode, depth int) contentModel {
+	model := contentModel{attributes: make([]*xmlNode, 0)}
+	for _, child := range complexType.Children {
+		switch child.Name.Local {
+		case "attribute":
+			model.attributes = append(model.attributes, child)
+		case "sequence", "choice", "all":
+			model.content = child
+		case "simpleContent", "complexContent":
+			for _, derivation := range child.Children {
+				if derivation.Name.Local != "extension" && derivation.Name.Local != "restriction" {
+					continue
+				}
+				base, _ := derivation.attr("base", "")
+				model = s.derivedModel(model, child.Name.Local == "simpleContent", derivation, base, depth)
+			}
+		}
+	}
+	return model
+}
+
+func (s *xsdSchema) derivedModel(model contentModel, simple bool, derivation *xmlNode, base string, depth int) contentModel {
+	baseModel := contentModel{attributes: make([]*xmlNode, 0)}
+	if check := s.typeCheck(base); check != nil {
+		baseModel.checks = append(baseModel.checks, check)
+	} else if complexType, found := s.complexTypes[localName(base)]; found && depth < maxDerivationDepth {
+		baseModel = s.contentModel(complexType, depth+1)
+	}
+
+	restriction := derivation.Name.Local == "restriction"
+	model.attributes = mergeAttributes(append(model.attributes, baseModel.attributes...), derivation.children("attribute", ""), restriction)
+	if simple {
+		model.simple = true
+		model.checks = append(model.checks, baseModel.checks...)
+		if restriction {
+			facets := &xmlNode{Children: []*xmlNode{{Name: derivation.Name, Children: derivation.Children}}}
+			model.checks = append(model.checks, func(value string) []string {
+				return s.checkSimpleType(value, facets)
+			})
+		}
+		return model
+	}
+
+	var particle *xmlNode
+	for _, item := range derivation.Children {
+		switch item.Name.Local {
+		case "sequence", "choice", "all":
+			particle = item
+		}
+	}
+	if restriction {
+		// A restriction repeats the content it keeps
+		model.content = particle
+	} else {
+		model.content = joinParticles(baseModel.content, particle)
+	}
+	return model
+}
+
+// typeCheck returns a function checking values against a built-in or simple type, nil if typeName refers to neither.
+func (s *xsdSchema) typeCheck(typeName string) func(string) []string {
+	if builtin, found := s.builtinType(typeName); found {
+		return func(value string) []string {
+			return checkBuiltinType(value, builtin)
+		}
+	}
+	if simpleType, found := s.simpleTypes[localName(typeName)]; found {
+		return func(value string) []string {
+			return s.checkSimpleType(value, simpleType)
+		}
+	}
+	return nil
+}
+
+// mergeAttributes adds the attributes declared by a derivation to the inherited ones. Attributes with the same name
+// replace inherited ones and prohibited attributes are removed in restrictions.
+func mergeAttributes(inherited []*xmlNode, declared []*xmlNode, restriction bool) []*xmlNode {
+	merged := make([]*xmlNode, 0, len(inherited)+len(declared))
+	names := make(map[string]bool)
+	for _, attribute := range declared {
+		name, _ := attribute.attr("name", "")
+		names[name] = true
+	}
+	for _, attribute := range inherited {
+		if name, _ := attribute.attr("name", ""); !names[name] {
+			merged = append(merged, attribute)
+		}
+	}
+	for _, attribute := range declared {
+		if use, _ := attribute.attr("use", ""); use != "prohibited" || !restriction {
+			merged = append(merged, attribute)
+		}
+	}
+	return merged
+}
+
+// joinParticles returns a sequence of both particles, which may be nil.
+func joinParticles(first *xmlNode, second *xmlNode) *xmlNode {
+	if first == nil {
+		return second
+	} else if second == nil {
+		return first
+	}
+	return &xmlNode{Name: xml.Name{Space: xsdNamespace, Local: "sequence"}, Children: []*xmlNode{first, second}}
+}
+
+func (s *xsdSchema) validateAttributes(node *xmlNode, declarations []*xmlNode, path string, messages *[]string) bool {
+	valid := true
+	declared := make(map[string]bool)
+
+	for _, declaration := range declarations {
+		name, _ := declaration.attr("name", "")
+		declared[name] = true
+
+		value, found := node.attr(name, "")
+		if !found {
+			if use, _ := declaration.attr("use", ""); use == "required" {
+				valid = false
+				*messages = append(*messages, fmt.Sprintf("missing attribute %s@%s", path, name))
+			}
+			continue
+		}
+
+		var problems []string
+		if typeName, found := declaration.attr("type", ""); found {
+			if builtin, isBuiltin := s.builtinType(typeName); isBuiltin {
+				problems = checkBuiltinType(value, builtin)
+			} else if simpleType, found := s.simpleTypes[localName(typeName)]; found {
+				problems = s.checkSimpleType(value, simpleType)
+			}
+		} else if simpleTypes := declaration.children("simpleType", ""); len(simpleTypes) > 0 {
+			problems = s.checkSimpleType(value, simpleTypes[0])
+		}
+		for _, problem := range problems {
+			valid = false
+			*messages = append(*messages, fmt.Sprintf("%s@%s %s", path, name, problem))
+		}
+	}
+
+	for _, attr := range node.Attrs {
+		if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" || attr.Name.Space == xsiNamespace {
+			continue
+		}
+		if !declared[attr.Name.Local] {
+			valid = false
+			*messages = append(*messages, fmt.Sprintf("unexpected attribute %s@%s", path, attr.Name.Local))
+		}
+	}
+	return valid
+}
+
+// occurs returns the minOccurs and maxOccurs of a particle. An unbounded maxOccurs is returned as math.MaxInt32.
+func occurs(particle *xmlNode) (int, int) {
+	min, max := 1, 1
+	if value, found := particle.attr("minOccurs", ""); found {
+		min, _ = strconv.Atoi(value)
+	}
+	if value, found := particle.attr("maxOccurs", ""); found {
+		if value == "unbounded" {
+			max = math.MaxInt32
+		} else {
+			max, _ = strconv.Atoi(value)
+		}
+	}
+	return min, max
+}
+
+// matchParticle greedily matches the children starting at index against a particle (element, sequence, choice, all or
+// any) as often as the particle may occur. It returns the index of the first child not matched and whether the children
+// are valid.
+func (s *xsdSchema) matchParticle(children []*xmlNode, index int, particle *xmlNode, path string, messages *[]string) (int, bool) {
+	min, max := occurs(particle)
+
+	count := 0
+	valid := true
+	var lastMessages []string
+	for count < max {
+		// Messages are only kept if children were consumed, otherwise the particle just doesn't occur again
+		msgs := make([]string, 0)
+		next, matched := s.matchOnce(children, index, particle, path, &msgs)
+		if next == index {
+			if matched && count < min {
+				// The particle matches without any children, e.g. a sequence of optional elements
+				count = min
+			}
+			lastMessages = msgs
+			break
+		}
+
+		// Children that were consumed but are invalid have already been reported, so matching continues after them
+		*messages = append(*messages, msgs...)
+		valid = matched && valid
+		index = next
+		count++
+	}
+
+	if count < min {
+		if len(lastMessages) > 0 {
+			*messages = append(*messages, lastMessages...)
+		} else {
+			*messages = append(*messages, fmt.Sprintf("%s misses %s", path, describeParticle(particle)))
+		}
+		return index, false
+	}
+	return index, valid
+}
+
+func (s *xsdSchema) matchOnce(children []*xmlNode, index int, particle *xmlNode, path string, messages *[]string) (int, bool) {
+	switch particle.Name.Local {
+	case "element":
+		return s.matchElement(children, index, particle, path, messages)
+	case "sequence":
+		return s.matchSequence(children, index, particle, path, messages)
+	case "choice":
+		return s.matchChoice(children, index, particle, path, messages)
+	case "all":
+		return s.matchAll(children, index, particle, path, messages)
+	case "any":
+		if index < len(children) {
+			return index + 1, true
+		}
+		return index, false
+	}
+	// Annotations and unsupported particles don't match any children
+	return index, true
+}
+
+func describeParticle(particle *xmlNode) string {
+	if particle.Name.Local == "element" {
+		name, found := particle.attr("name", "")
+		if !found {
+			name, _ = particle.attr("ref", "")
+		}
+		return "element " + localName(name)
+	}
+	return particle.Name.Local
+}
+
+func (s *xsdSchema) matchElement(children []*xmlNode, index int, particle *xmlNode, path string, messages *[]string) (int, bool) {
+	name, found := particle.attr("name", "")
+	if !found {
+		ref, _ := particle.attr("ref", "")
+		name = localName(ref)
+	}
+
+	if index >= len(children) || children[index].Name.Local != name {
+		return index, false
+	}
+
+	return index + 1, s.validateElement(children[index], particle, path+"."+name, messages)
+}
+
+func (s *xsdSchema) matchSequence(children []*xmlNode, index int, particle *xmlNode, path string, messages *[]string) (int, bool) {
+	valid := true
+	for _, item := range particle.Children {
+		var itemValid bool
+		index, itemValid = s.matchParticle(children, index, item, path, messages)
+		valid = itemValid && valid
+	}
+	return index, valid
+}
+
+func (s *xsdSchema) matchChoice(children []*xmlNode, index int, particle *xmlNode, path string, messages *[]string) (int, bool) {
+	matchesEmpty := false
+	for _, item := range particle.Children {
+		msgs := make([]string, 0)
+		next, valid := s.matchParticle(children, index, item, path, &msgs)
+		if next > index {
+			*messages = append(*messages, msgs...)
+			return next, valid
+		}
+		matchesEmpty = matchesEmpty || valid
+	}
+	return index, matchesEmpty
+}
+
+func (s *xsdSchema) matchAll(children []*xmlNode, index int, particle *xmlNode, path string, messages *[]string) (int, bool) {
+	valid := true
+	matched := make(map[*xmlNode]bool)
+
+	for progress := true; progress; {
+		progress = false
+		for _, item := range particle.Children {
+			if matched[item] {
+				continue
+			}
+			if next, itemValid := s.matchElement(children, index, item, path, messages); next > index {
+				matched[item] = true
+				valid = itemValid && valid
+				index = next
+				progress = true
+			}
+		}
+	}
+
+	for _, item := range particle.Children {
+		if min, _ := occurs(item); item.Name.Local == "element" && !matched[item] && min > 0 {
+			valid = false
+			*messages = append(*messages, fmt.Sprintf("%s misses %s", path, describeParticle(item)))
+		}
+	}
+	return index, valid
+}
+
+// checkSimpleType checks a value against a simpleType with a restriction and returns the problems found.
+func (s *xsdSchema) checkSimpleType(value string, simpleType *xmlNode) []string {
+	restrictions := simpleType.children("restriction", "")
+	if len(restrictions) == 0 {
+		// Lists and unions are rejected when the schema is loaded
+		return nil
+	}
+	restriction := restrictions[0]
+
+	problems := make([]string, 0)
+	if base, found := restriction.attr("base", ""); found {
+		if builtin, isBuiltin := s.builtinType(base); isBuiltin {
+			problems = append(problems, checkBuiltinType(value, builtin)...)
+		} else if baseType, found := s.simpleTypes[localName(base)]; found {
+			problems = append(problems, s.checkSimpleType(value, baseType)...)
+		}
+	}
+
+	enumeration := make([]string, 0)
+	for _, facet := range restriction.Children {
+		facetValue, _ := facet.attr("value", "")
+		switch facet.Name.Local {
+		case "enumeration":
+			enumeration = append(enumeration, facetValue)
+		case "length", "minLength", "maxLength":
+			length, _ := strconv.Atoi(facetValue)
+			actual := len([]rune(value))
+			if (facet.Name.Local == "length" && actual != length) ||
+				(facet.Name.Local == "minLength" && actual < length) ||
+				(facet.Name.Local == "maxLength" && actual > length) {
+				problems = append(problems, fmt.Sprintf("has length %d not %s %d", actual, facet.Name.Local, length))
+			}
+		case "minInclusive", "maxInclusive", "minExclusive", "maxExclusive":
+			if problem := checkRange(value, facet.Name.Local, facetValue); problem != "" {
+				problems = append(problems, problem)
+			}
+		}
+	}
+
+	if facets := restriction.children("pattern", ""); len(facets) > 0 && !s.patterns[facets[0]].MatchString(value) {
+		problems = append(problems, "doesn't match pattern "+strings.Join(facetValues(facets), " or "))
+	}
+	if len(enumeration) > 0 && !containsString(enumeration, value) {
+		problems = append(problems, fmt.Sprintf("is %s not one of %s", value, strings.Join(enumeration, ", ")))
+	}
+	return problems
+}
+
+func facetValues(facets []*xmlNode) []string {
+	values := make([]string, 0, len(facets))
+	for _, facet := range facets {
+		value, _ := facet.attr("value", "")
+		values = append(values, value)
+	}
+	return values
+}
+
+func checkRange(value string, facet string, limit string) string {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return ""
+	}
+	l, err := strconv.ParseFloat(limit, 64)
+	if err != nil {
+		return ""
+	}
+
+	if (facet == "minInclusive" && v < l) || (facet == "maxInclusive" && v > l) ||
+		(facet == "minExclusive" && v <= l) || (facet == "maxExclusive" && v >= l) {
+		return fmt.Sprintf("is %s not %s %s", value, facet, limit)
+	}
+	return ""
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// checkBuiltinType checks a value against a built-in type of XML Schema. Types that are not known accept every value,
+// but are rejected when the schema is loaded.
+func checkBuiltinType(value string, builtin string) []string {
+	if builtin != "string" && builtin != "normalizedString" {
+		value = strings.TrimSpace(value)
+	}
+
+	valid := true
+	if limits, isInteger := xsdIntegerRanges[builtin]; isInteger {
+		valid = checkInteger(value, limits[0], limits[1])
+	}
+	switch builtin {
+	case "boolean":
+		valid = value == "true" || value == "false" || value == "1" || value == "0"
+	case "decimal":
+		valid = xsdDecimalPattern.MatchString(value)
+	case "float", "double":
+		_, err := strconv.ParseFloat(value, 64)
+		valid = err == nil || value == "INF" || value == "-INF" || value == "NaN"
+	case "date":
+		match := xsdDatePattern.FindStringSubmatch(value)
+		valid = match != nil && validTime("2006-01-02", match[1])
+	case "time":
+		match := xsdTimePattern.FindStringSubmatch(value)
+		valid = match != nil && validTime("15:04:05", match[1])
+	case "dateTime":
+		match := xsdDateTimePattern.FindStringSubmatch(value)
+		valid = match != nil && validTime("2006-01-02T15:04:05", match[1]+"T"+match[2])
+	case "anyURI":
+		_, err := url.Parse(value)
+		valid = err == nil
+	case "base64Binary":
+		_, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(value), ""))
+		valid = err == nil
+	case "hexBinary":
+		_, err := hex.DecodeString(value)
+		valid = err == nil
+	}
+
+	if !valid {
+		return []string{fmt.Sprintf("is %q not %s", value, builtin)}
+	}
+	return nil
+}
+
+// checkInteger checks if the value is an integer within the limits, an empty limit means no limit.
+func checkInteger(value string, min string, max string) bool {
+	parsed, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return false
+	}
+	if limit, ok := new(big.Int).SetString(min, 10); ok && parsed.Cmp(limit) < 0 {
+		return false
+	}
+	if limit, ok := new(big.Int).SetString(max, 10); ok && parsed.Cmp(limit) > 0 {
+		return false
+	}
+	return true
+}
+
+func validTime(layout string, value string) bool {
+	_, err := time.Parse(layout, value)
+	return err == nil
+}
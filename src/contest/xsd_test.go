@@ -0,0 +1,169 @@
+package contest
+
+import (
+	"strings"
+	"testing"
+)
+
+const testXsd = `<?xml version="1.0"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:complexType name="Animal">
+    <xs:sequence>
+      <xs:element name="name" type="xs:string"/>
+    </xs:sequence>
+    <xs:attribute name="id" type="xs:int" use="required"/>
+  </xs:complexType>
+  <xs:complexType name="Dog">
+    <xs:complexContent>
+      <xs:extension base="Animal">
+        <xs:sequence>
+          <xs:element name="breed" type="xs:string" minOccurs="0"/>
+        </xs:sequence>
+      </xs:extension>
+    </xs:complexContent>
+  </xs:complexType>
+  <xs:complexType name="Price">
+    <xs:simpleContent>
+      <xs:extension base="xs:decimal">
+        <xs:attribute name="currency" type="xs:string"/>
+      </xs:extension>
+    </xs:simpleContent>
+  </xs:complexType>
+  <xs:element name="dog" type="Dog"/>
+  <xs:element name="price" type="Price"/>
+  <xs:element name="date" type="xs:date"/>
+  <xs:element name="dateTime" type="xs:dateTime"/>
+  <xs:element name="time" type="xs:time"/>
+  <xs:element name="int" type="xs:int"/>
+  <xs:element name="short" type="xs:short"/>
+  <xs:element name="byte" type="xs:byte"/>
+  <xs:element name="unsignedByte" type="xs:unsignedByte"/>
+  <xs:element name="negativeInteger" type="xs:negativeInteger"/>
+  <xs:element name="decimal" type="xs:decimal"/>
+  <xs:simpleType name="Code">
+    <xs:restriction base="xs:string">
+      <xs:pattern value="[A-Z]{3}"/>
+      <xs:pattern value="\d{3}"/>
+    </xs:restriction>
+  </xs:simpleType>
+  <xs:element name="code" type="Code"/>
+</xs:schema>`
+
+func TestXsdValidate(t *testing.T) {
+	schema, err := parseXsd([]byte(testXsd))
+	if err != nil {
+		t.Fatalf("could not parse schema: %s", err)
+	}
+
+	tests := []struct {
+		document string
+		valid    bool
+	}{
+		{`<dog id="1"><name>Rex</name></dog>`, true},
+		{`<dog id="1"><name>Rex</name><breed>Pug</breed></dog>`, true},
+		{`<dog id="1"><breed>Pug</breed></dog>`, false},
+		{`<dog><name>Rex</name></dog>`, false},
+		{`<dog id="1"><name>Rex</name><color>brown</color></dog>`, false},
+		{`<price currency="EUR">1.50</price>`, true},
+		{`<price>cheap</price>`, false},
+		{`<price>1<cents>50</cents></price>`, false},
+		{`<date>2020-01-01</date>`, true},
+		{`<date>2020-01-01Z</date>`, true},
+		{`<date>2020-01-01+02:00</date>`, true},
+		{`<date>2020-13-01</date>`, false},
+		{`<dateTime>2020-01-01T00:00:00</dateTime>`, true},
+		{`<dateTime>2020-01-01T00:00:00.123Z</dateTime>`, true},
+		{`<dateTime>2020-01-01T00:00:00-05:00</dateTime>`, true},
+		{`<dateTime>2020-01-01</dateTime>`, false},
+		{`<time>13:20:00</time>`, true},
+		{`<time>25:00:00</time>`, false},
+		{`<int>2147483647</int>`, true},
+		{`<int>2147483648</int>`, false},
+		{`<short>-32768</short>`, true},
+		{`<short>40000</short>`, false},
+		{`<byte>128</byte>`, false},
+		{`<unsignedByte>255</unsignedByte>`, true},
+		{`<unsignedByte>-1</unsignedByte>`, false},
+		{`<negativeInteger>0</negativeInteger>`, false},
+		{`<decimal>-1.5</decimal>`, true},
+		{`<decimal>1e5</decimal>`, false},
+		{`<code>ABC</code>`, true},
+		{`<code>123</code>`, true},
+		{`<code>AB1</code>`, false},
+	}
+	for _, test := range tests {
+		root, err := parseXml([]byte(test.document))
+		if err != nil {
+			t.Fatalf("could not parse %s: %s", test.document, err)
+		}
+		messages := make([]string, 0)
+		if valid := schema.Validate(root, &messages); valid != test.valid {
+			t.Errorf("%s: valid is %t not %t (%s)", test.document, valid, test.valid, strings.Join(messages, ", "))
+		}
+	}
+}
+
+func TestXsdComplexContentRestriction(t *testing.T) {
+	schema, err := parseXsd([]byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:complexType name="Base">
+    <xs:sequence>
+      <xs:element name="a" type="xs:string"/>
+      <xs:element name="b" type="xs:string" minOccurs="0"/>
+    </xs:sequence>
+    <xs:attribute name="legacy" type="xs:string"/>
+  </xs:complexType>
+  <xs:complexType name="Restricted">
+    <xs:complexContent>
+      <xs:restriction base="Base">
+        <xs:sequence>
+          <xs:element name="a" type="xs:string"/>
+        </xs:sequence>
+        <xs:attribute name="legacy" use="prohibited"/>
+      </xs:restriction>
+    </xs:complexContent>
+  </xs:complexType>
+  <xs:element name="root" type="Restricted"/>
+</xs:schema>`))
+	if err != nil {
+		t.Fatalf("could not parse schema: %s", err)
+	}
+
+	tests := []struct {
+		document string
+		valid    bool
+	}{
+		{`<root><a>x</a></root>`, true},
+		{`<root><a>x</a><b>y</b></root>`, false},
+		{`<root legacy="1"><a>x</a></root>`, false},
+	}
+	for _, test := range tests {
+		root, _ := parseXml([]byte(test.document))
+		messages := make([]string, 0)
+		if valid := schema.Validate(root, &messages); valid != test.valid {
+			t.Errorf("%s: valid is %t not %t (%s)", test.document, valid, test.valid, strings.Join(messages, ", "))
+		}
+	}
+}
+
+func TestXsdUnsupported(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+	}{
+		{"list", `<xs:simpleType name="L"><xs:list itemType="xs:int"/></xs:simpleType>`},
+		{"union", `<xs:simpleType name="U"><xs:union memberTypes="xs:int xs:date"/></xs:simpleType>`},
+		{"group", `<xs:group name="G"><xs:sequence/></xs:group>`},
+		{"import", `<xs:import namespace="urn:other"/>`},
+		{"unknown builtin", `<xs:element name="d" type="xs:duration"/>`},
+		{"unknown type", `<xs:element name="d" type="Missing"/>`},
+		{"XML schema pattern escape", `<xs:simpleType name="N"><xs:restriction base="xs:string"><xs:pattern value="\i\c*"/></xs:restriction></xs:simpleType>`},
+		{"XML schema block escape", `<xs:simpleType name="B"><xs:restriction base="xs:string"><xs:pattern value="\p{IsBasicLatin}+"/></xs:restriction></xs:simpleType>`},
+		{"fraction digits", `<xs:simpleType name="F"><xs:restriction base="xs:decimal"><xs:fractionDigits value="2"/></xs:restriction></xs:simpleType>`},
+	}
+	for _, test := range tests {
+		schema := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">` + test.schema + `</xs:schema>`
+		if _, err := parseXsd([]byte(schema)); err == nil {
+			t.Errorf("%s: schema was accepted", test.name)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"contract-testing/src/serialization/openapi"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/logrusorgru/aurora/v3"
+	"log"
+	"os"
+)
+
+// diffReport is the JSON output of the diff command.
+type diffReport struct {
+	Breaking    int              `json:"breaking"`
+	NonBreaking int              `json:"nonBreaking"`
+	Changes     []openapi.Change `json:"changes"`
+}
+
+func runDiff(args []string) {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	format := flags.String("format", "text", "The output format, text or json")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: contest diff [-format text|json] old.yaml new.yaml\n")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 2 || (*format != "text" && *format != "json") {
+		flags.Usage()
+		os.Exit(2)
+	}
+	oldPath, newPath := flags.Arg(0), flags.Arg(1)
+	checkFilePointer(&oldPath)
+	checkFilePointer(&newPath)
+
+	oldDoc, err := openapi.LoadDocument(oldPath)
+	if err != nil {
+		log.Fatalln("Could not load OpenAPI document", oldPath, ":", err)
+	}
+	newDoc, err := openapi.LoadDocument(newPath)
+	if err != nil {
+		log.Fatalln("Could not load OpenAPI document", newPath, ":", err)
+	}
+
+	report := diffReport{Changes: openapi.Diff(oldDoc, newDoc)}
+	for _, change := range report.Changes {
+		if change.Level == openapi.ChangeBreaking {
+			report.Breaking++
+		} else {
+			report.NonBreaking++
+		}
+	}
+
+	if *format == "json" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			log.Fatalln("Could not encode the changes", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		fmt.Printf("Comparing %s with %s...\n\n", oldPath, newPath)
+		for _, change := range report.Changes {
+			level := aurora.Green("NON-BREAKING")
+			if change.Level == openapi.ChangeBreaking {
+				level = aurora.Red("BREAKING")
+			}
+			fmt.Printf("[%s] %s\n", level, change)
+		}
+		if len(report.Changes) > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%d breaking and %d non-breaking changes.\n", report.Breaking, report.NonBreaking)
+	}
+
+	if report.Breaking > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"contract-testing/src/contest"
+	"contract-testing/src/serialization"
+	"flag"
+	"fmt"
+	"github.com/logrusorgru/aurora/v3"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func runFuzz(args []string) {
+	flags := flag.NewFlagSet("fuzz", flag.ExitOnError)
+	suiteFileP := flags.String("suite", "./contest.yaml", "The path to the suite with the spec files to fuzz")
+	seedP := flags.Int64("seed", 0, "Seed for the generated inputs (default: random)")
+	runsP := flags.Int("runs", 100, "Number of inputs per operation")
+	_ = flags.Parse(args)
+
+	checkFilePointer(suiteFileP)
+	suite, err := serialization.LoadSuite(*suiteFileP)
+	if err != nil {
+		log.Fatalln("Could not load Suite YAML", err)
+	}
+
+	targets := make([]serialization.FuzzTarget, 0)
+	for _, specFile := range suite.SpecFiles {
+		specTargets, err := specFile.CreateFuzzTargets()
+		if err != nil {
+			log.Fatalln("Could not create fuzz targets for spec file", specFile.Path, ":", err)
+		}
+		targets = append(targets, specTargets...)
+	}
+
+	seed := *seedP
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	fmt.Printf("Fuzzing %d operations with seed %d...\n\n", len(targets), seed)
+
+	// On an interrupt, requests in flight are cancelled and the remaining operations are not fuzzed
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	failedTargets := 0
+	fuzzedTargets := 0
+	for _, target := range targets {
+		if ctx.Err() != nil {
+			break
+		}
+		failures := contest.FuzzOperation(ctx, target, *suite, seed, *runsP)
+		fuzzedTargets++
+		if len(failures) == 0 {
+			fmt.Printf("[%s] %s (%d inputs)\n", PassWarnFail(contest.ContractPass), target.Contract.Name, *runsP)
+			continue
+		}
+
+		failedTargets++
+		fmt.Printf("[%s] %s (%d inputs, %d failures)\n", PassWarnFail(contest.ContractFail), target.Contract.Name, *runsP, len(failures))
+		for _, failure := range failures {
+			fmt.Printf("       %s\n", joinFailures(failure.Result.Failures))
+			input := "input: " + failure.Input.String()
+			if failure.Input.Mutation != "" {
+				input += " (" + failure.Input.Mutation + ")"
+			}
+			fmt.Println(aurora.Faint("       " + input))
+		}
+	}
+
+	interrupted := ctx.Err() != nil
+	stop()
+
+	fmt.Println()
+	if interrupted {
+		fmt.Printf("Interrupted after %d of %d operations.\n", fuzzedTargets, len(targets))
+	}
+	fmt.Printf("%d/%d operations passed.\n", fuzzedTargets-failedTargets, len(targets))
+	if failedTargets > 0 {
+		fmt.Printf("Reproduce with: contest fuzz -suite %s -seed %d -runs %d\n", *suiteFileP, seed, *runsP)
+		os.Exit(1)
+	}
+}
@@ -1,22 +1,33 @@
 package main
 
 import (
+	"context"
+	"contract-testing/src/contest"
 	"contract-testing/src/serialization"
 	"contract-testing/src/serialization/openapi"
+	"errors"
 	"flag"
 	"fmt"
 	"github.com/logrusorgru/aurora/v3"
 	"log"
 	"os"
+	"os/signal"
 	"strings"
-	"sync"
+	"syscall"
+	"time"
 )
 
-func PassWarnFail(i ContractVerdict) aurora.Value {
-	if i >= ContractFail {
+func PassWarnFail(i contest.ContractVerdict) aurora.Value {
+	if i >= contest.ContractFail {
 		return aurora.Red("FAIL")
-	} else if i >= ContractWarn {
+	} else if i >= contest.ContractXPass {
+		return aurora.Yellow("XPASS")
+	} else if i >= contest.ContractXFail {
+		return aurora.Magenta("XFAIL")
+	} else if i >= contest.ContractWarn {
 		return aurora.Yellow("WARN")
+	} else if i >= contest.ContractInfo {
+		return aurora.Cyan("INFO")
 	}
 	return aurora.Green("PASS")
 }
@@ -44,8 +55,31 @@ func checkFilePointer(p *string) {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "fuzz":
+			runFuzz(os.Args[2:])
+			return
+		case "diff":
+			runDiff(os.Args[2:])
+			return
+		}
+	}
+
 	suiteFileP := flag.String("suite", "./contest.yaml", "The path to the suite to run on")
 	numWorkers := flag.Int("workers", 1, "Number of workers")
+	repeat := flag.Int("repeat", 0, "Load mode: run every contract this many times")
+	duration := flag.Duration("duration", 0, "Load mode: run every contract for this duration, e.g. 30s")
+	concurrency := flag.Int("concurrency", 1, "Load mode: number of concurrent runs of a contract")
+	shuffle := flag.Bool("shuffle", false, "Run the contracts in random order")
+	seed := flag.Int64("seed", 0, "Seed of the random order with -shuffle (default: random)")
+	rate := flag.Float64("rate", 0, "Maximum number of requests per second (default: no limit)")
+	timeout := flag.Duration("timeout", 0, "Maximum duration of the whole run, e.g. 5m (default: no limit)")
+	reportFile := flag.String("report", "", "Write an HTML report with the requests and responses to this path")
+	saveFile := flag.String("save", "", "Save the results of the run as JSON to this path")
+	baselineFile := flag.String("baseline", "", "Compare with the results saved at this path and only fail on new failures")
+	threshold := flag.Float64("regression-threshold", 50, "Increase of a response time in percent reported as regression")
+	updateSnapshots := flag.Bool("update-snapshots", false, "Rewrite the snapshot files with the response bodies")
 	var schemaFilesP multiStringFlag
 	flag.Var(&schemaFilesP, "schema", "Path to an OpenAPI 3.0 schema file (multiple allowed)")
 	flag.Parse()
@@ -59,10 +93,19 @@ func main() {
 	if err != nil {
 		log.Fatalln("Could not load Suite YAML", err)
 	}
+	suite.UpdateSnapshots = *updateSnapshots
 	if *suiteFileP == "./contest.yaml" {
 		fmt.Printf("Using testing suite from contest.yaml.\n\n")
 	}
 
+	var baseline *contest.SavedRun
+	if *baselineFile != "" {
+		baseline, err = contest.LoadRun(*baselineFile)
+		if err != nil {
+			log.Fatalln("Could not load baseline", err)
+		}
+	}
+
 	// Load all schemas from OpenAPI documents
 	suite.Schemas = make(map[string]openapi.Schema)
 	for _, path := range schemaFilesP {
@@ -75,88 +118,203 @@ func main() {
 		}
 	}
 
-	// Load spec files and create contracts for all operations listed
-	for _, specFile := range suite.SpecFiles {
-		contracts, err := specFile.CreateContracts()
-		if err != nil {
-			log.Fatalln("Could not create contracts for spec file", specFile.Path, ":", err)
-		}
-
-		suite.Contracts = append(suite.Contracts, contracts...)
+	contracts, err := contest.CreateContracts(*suite)
+	if err != nil {
+		log.Fatalln("Could not create contracts:", err)
 	}
 
-	var warningFailureReasons []FailureReason
-	for failureReason, severity := range suite.Severity {
-		if strings.ToLower(severity) == "warn" {
-			if warningFailureReasons == nil {
-				warningFailureReasons = make([]FailureReason, 0)
-			}
-			warningFailureReasons = append(warningFailureReasons, FailureReason(failureReason))
-		}
+	if *shuffle && *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	if *shuffle {
+		fmt.Printf("Testing %d contracts in random order with seed %d...\n\n", len(contracts), *seed)
+	} else {
+		fmt.Printf("Testing %d contracts...\n\n", len(contracts))
 	}
 
-	fmt.Printf("Testing %d contracts...\n\n", len(suite.Contracts))
-
-	successfulContracts := 0
-	verdict := ContractPass
+	// The results are printed in the order of the suite once all contracts have run, the progress is shown on stderr
+	finished := 0
+	options := contest.Options{
+		Workers: *numWorkers,
+		Rate:    *rate,
+		Shuffle: *shuffle,
+		Seed:    *seed,
 
-	jobs := make(chan serialization.Contract, len(suite.Contracts))
-	results := make(chan ContractResult, 0)
-	for w := 0; w < *numWorkers; w++ {
-		go worker(jobs, results, *suite, &warningFailureReasons)
+		Repeat:      *repeat,
+		Duration:    *duration,
+		Concurrency: *concurrency,
+		OnResult: func(res contest.ContractResult) {
+			finished++
+			fmt.Fprintf(os.Stderr, "[%d/%d] [%s] %s\n", finished, len(contracts), resultStatus(res), res.Name)
+		},
 	}
 
-	// Send contracts to workers
-	var wg sync.WaitGroup
-	go func() {
-		for _, contract := range suite.Contracts {
-			wg.Add(1)
-			jobs <- contract
-		}
-		close(jobs)
+	// On an interrupt or when the timeout is exceeded, requests in flight are cancelled and the remaining contracts
+	// are skipped, so the summary can still be printed
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
 
-		wg.Wait()
-		close(results)
-	}()
+	report, err := contest.RunContracts(ctx, contracts, *suite, options)
+	if report == nil {
+		log.Fatalln("Could not run contracts:", err)
+	}
+	stop()
 
-	// Handle results from workers
-	for res := range results {
-		pass := res.Pass(&warningFailureReasons)
-		verdict |= pass
+	for _, res := range report.Results {
+		printResult(res)
+	}
 
-		if pass < ContractFail {
-			successfulContracts++
+	if *reportFile != "" {
+		if reportErr := writeReport(*reportFile, report); reportErr != nil {
+			log.Println("Could not write report:", reportErr)
 		}
+	}
 
-		postfix := ""
-		if len(res.Failures) > 0 {
-			for _, failure := range res.Failures {
-				postfix += failure.String() + "; "
-			}
-			postfix = strings.TrimSuffix(postfix, "; ")
-			postfix = " " + aurora.Faint("("+postfix+")").String()
+	run := contest.NewSavedRun(report, baseline)
+	if *saveFile != "" {
+		if saveErr := contest.SaveRun(*saveFile, run); saveErr != nil {
+			log.Println("Could not save results:", saveErr)
 		}
-		fmt.Printf("[%s] %s%s\n", PassWarnFail(pass), res.Name, postfix)
-		wg.Done()
+	}
+
+	failed := report.Failed()
+	knownInBaseline := false
+	if baseline != nil {
+		comparison := contest.Compare(*baseline, run, *threshold)
+		printComparison(*baselineFile, comparison)
+		knownInBaseline = failed && len(comparison.NewFailures) == 0
+		failed = len(comparison.NewFailures) > 0
 	}
 
 	fmt.Println()
-	fmt.Printf("%d/%d contracts passed.\n", successfulContracts, len(suite.Contracts))
-	fmt.Printf("Final verdict: %s\n", aurora.Bold(PassWarnFail(verdict)))
+	if errors.Is(err, context.DeadlineExceeded) {
+		fmt.Printf("Timeout of %s exceeded.\n", *timeout)
+	} else if err != nil {
+		fmt.Println("Interrupted.")
+	}
+	summary := fmt.Sprintf("%d/%d contracts passed", report.Passed, len(report.Results))
+	if report.KnownFailures > 0 {
+		summary += fmt.Sprintf(", %d known failures", report.KnownFailures)
+	}
+	if report.UnexpectedPasses > 0 {
+		summary += fmt.Sprintf(", %d unexpected passes", report.UnexpectedPasses)
+	}
+	if report.Skipped > 0 {
+		summary += fmt.Sprintf(", %d skipped", report.Skipped)
+	}
+	fmt.Printf("%s.\n", summary)
+	fmt.Printf("Final verdict: %s\n", aurora.Bold(PassWarnFail(report.Verdict)))
+	if knownInBaseline && err == nil {
+		fmt.Printf("Exiting with status 0, because every failing contract also failed in %s.\n", *baselineFile)
+	}
 
-	if successfulContracts < len(suite.Contracts) {
+	if *shuffle && report.Failed() {
+		fmt.Printf("Reproduce the order with: contest -suite %s -shuffle -seed %d\n", *suiteFileP, *seed)
+	}
+
+	if failed || err != nil {
 		os.Exit(1)
 	}
 }
 
-func worker(
-	jobs <-chan serialization.Contract,
-	results chan<- ContractResult,
-	suite serialization.Suite,
-	warningFailureReasons *[]FailureReason,
-) {
-	for contract := range jobs {
-		res := RunContract(contract, suite, warningFailureReasons)
-		results <- res
+// writeReport writes the HTML report of the run to the file at path.
+func writeReport(path string, report *contest.Report) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := contest.WriteHtmlReport(file, report); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// printComparison prints the changes compared with the baseline run.
+func printComparison(baselinePath string, comparison contest.Comparison) {
+	fmt.Printf("\nCompared with %s:\n", baselinePath)
+	for _, name := range comparison.NewFailures {
+		fmt.Printf("[%s] %s\n", aurora.Red("NEW FAIL"), name)
+	}
+	for _, name := range comparison.NewPasses {
+		fmt.Printf("[%s] %s\n", aurora.Green("NEW PASS"), name)
+	}
+	for _, name := range comparison.Flaky {
+		fmt.Printf("[%s] %s\n", aurora.Yellow("FLAKY"), name)
+	}
+	for _, regression := range comparison.Regressions {
+		fmt.Printf("[%s] %s %s\n", aurora.Yellow("SLOWER"), regression.Name,
+			aurora.Faint(fmt.Sprintf("(%dms, was %dms)", regression.Current, regression.Baseline)))
+	}
+	fmt.Printf("%d new failures, %d new passes, %d flaky, %d response time regressions.\n", len(comparison.NewFailures),
+		len(comparison.NewPasses), len(comparison.Flaky), len(comparison.Regressions))
+}
+
+// printResult prints the status and failures of a contract.
+func printResult(res contest.ContractResult) {
+	postfix := ""
+	if len(res.Failures) > 0 {
+		postfix = " " + aurora.Faint("("+joinFailures(res.Failures)+")").String()
+	}
+	if res.Attempts > 1 {
+		postfix = fmt.Sprintf(" [%d attempts]", res.Attempts) + postfix
+	}
+	fmt.Printf("[%s] %s%s\n", resultStatus(res), res.Name, postfix)
+	for i, failures := range res.FailedAttempts {
+		fmt.Println(aurora.Faint(fmt.Sprintf("       attempt %d: %s", i+1, joinFailures(failures))))
+	}
+	if res.Load != nil {
+		printLoadStats(*res.Load)
+	}
+}
+
+// loadHistogramWidth is the width of the longest bar of a latency histogram.
+const loadHistogramWidth = 40
+
+// printLoadStats prints the error rate, percentiles and histogram of the response times of a contract in load mode.
+func printLoadStats(stats contest.LoadStats) {
+	summary := fmt.Sprintf("%d runs in %s, %.1f%% errors", stats.Runs, stats.Duration.Round(time.Millisecond), stats.ErrorRate()*100)
+	if len(stats.Latencies) > 0 {
+		summary += fmt.Sprintf(", p50 %dms, p90 %dms, p99 %dms, max %dms",
+			stats.Percentile(50), stats.Percentile(90), stats.Percentile(99), stats.Latencies[len(stats.Latencies)-1])
+	}
+	fmt.Println(aurora.Faint("       " + summary))
+
+	histogram := stats.Histogram()
+	maxCount := 0
+	for _, bucket := range histogram {
+		if bucket.Count > maxCount {
+			maxCount = bucket.Count
+		}
+	}
+	for _, bucket := range histogram {
+		bound := fmt.Sprintf("<= %dms", bucket.UpperBound)
+		if bucket.UpperBound < 0 {
+			bound = "slower"
+		}
+		bar := strings.Repeat("#", (bucket.Count*loadHistogramWidth+maxCount-1)/maxCount)
+		fmt.Println(aurora.Faint(fmt.Sprintf("       %10s %s %d", bound, bar, bucket.Count)))
+	}
+}
+
+// resultStatus returns the status printed for a result.
+func resultStatus(res contest.ContractResult) aurora.Value {
+	if res.Skipped {
+		return aurora.Faint("SKIP")
+	}
+	return PassWarnFail(res.Verdict)
+}
+
+// joinFailures joins the descriptions of the failures.
+func joinFailures(failures []contest.Failure) string {
+	descriptions := make([]string, len(failures))
+	for i, failure := range failures {
+		descriptions[i] = failure.String()
 	}
+	return strings.Join(descriptions, "; ")
 }
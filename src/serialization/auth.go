@@ -0,0 +1,288 @@
+package serialization
+
+import (
+	"contract-testing/src/serialization/openapi"
+	"fmt"
+	"github.com/logrusorgru/aurora/v3"
+	"sort"
+	"strings"
+)
+
+type AuthType string
+
+const (
+	AuthTypeNone   AuthType = "none"   // Don't authenticate, even if the suite has credentials
+	AuthTypeBasic  AuthType = "basic"  // HTTP basic authentication with Username and Password
+	AuthTypeBearer AuthType = "bearer" // A static bearer Token
+	AuthTypeApiKey AuthType = "apiKey" // An API key Value with the Name in the header, query or cookie (In)
+	AuthTypeOAuth2 AuthType = "oauth2" // A bearer token fetched from TokenUrl with the client credentials or password Grant
+)
+
+const (
+	OAuth2GrantClientCredentials = "client_credentials"
+	OAuth2GrantPassword          = "password"
+)
+
+// Auth describes the credentials used to authenticate a request.
+type Auth struct {
+	Type AuthType `yaml:"type"`
+
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	Token    string `yaml:"token"`
+
+	Name  string              `yaml:"name"`
+	In    openapi.ParameterIn `yaml:"in"`
+	Value string              `yaml:"value"`
+
+	TokenUrl     string   `yaml:"tokenUrl"`
+	Grant        string   `yaml:"grant"`
+	ClientId     string   `yaml:"clientId"`
+	ClientSecret string   `yaml:"clientSecret"`
+	Scopes       []string `yaml:"scopes"`
+}
+
+// Auths is a list of credentials which are all used to authenticate a request. In YAML, either a single Auth or a list
+// of them can be given.
+type Auths []Auth
+
+func (a *Auths) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var auth Auth
+	if err := unmarshal(&auth); err == nil {
+		*a = Auths{auth}
+		return nil
+	}
+
+	var auths []Auth
+	if err := unmarshal(&auths); err != nil {
+		return err
+	}
+	*a = auths
+	return nil
+}
+
+// Disabled checks if the credentials disable authentication.
+func (a Auths) Disabled() bool {
+	for _, auth := range a {
+		if auth.Type == AuthTypeNone {
+			return true
+		}
+	}
+	return false
+}
+
+// WithScheme returns a copy of the credentials in which the values not set are taken from an OpenAPI security scheme.
+func (a Auth) WithScheme(scheme openapi.SecurityScheme, scopes []string) Auth {
+	switch scheme.Type {
+	case openapi.SecuritySchemeTypeHttp:
+		if a.Type == "" && strings.EqualFold(scheme.Scheme, "basic") {
+			a.Type = AuthTypeBasic
+		} else if a.Type == "" && strings.EqualFold(scheme.Scheme, "bearer") {
+			a.Type = AuthTypeBearer
+		}
+	case openapi.SecuritySchemeTypeApiKey:
+		if a.Type == "" {
+			a.Type = AuthTypeApiKey
+		}
+		if a.Name == "" {
+			a.Name = scheme.Name
+		}
+		if a.In == "" {
+			a.In = scheme.In
+		}
+	case openapi.SecuritySchemeTypeOAuth2:
+		if a.Type == "" {
+			a.Type = AuthTypeOAuth2
+		}
+		if a.Grant == "" && scheme.Flows.ClientCredentials != nil {
+			a.Grant = OAuth2GrantClientCredentials
+		} else if a.Grant == "" && scheme.Flows.Password != nil {
+			a.Grant = OAuth2GrantPassword
+		}
+
+		flow := scheme.Flows.ClientCredentials
+		if a.Grant == OAuth2GrantPassword {
+			flow = scheme.Flows.Password
+		}
+		if a.TokenUrl == "" && flow != nil {
+			a.TokenUrl = flow.TokenUrl
+		}
+		if a.Scopes == nil {
+			a.Scopes = scopes
+		}
+	}
+	return a
+}
+
+// findSecurityAuths returns the credentials for the first security requirement of an operation for which credentials
+// are given. Credentials are given per security scheme name. ok is false if the operation requires security, but no
+// requirement can be satisfied. An explicitly empty list of requirements, or an empty requirement, disables
+// authentication, so the credentials of the suite are not sent either.
+func findSecurityAuths(doc *openapi.Document, operation openapi.Operation, credentials map[string]Auth) (Auths, bool) {
+	requirements := doc.FindSecurity(operation)
+	if requirements == nil {
+		return nil, true
+	} else if len(requirements) == 0 {
+		return Auths{{Type: AuthTypeNone}}, true
+	}
+
+requirements:
+	for _, requirement := range requirements {
+		auths := make(Auths, 0, len(requirement))
+		for name, scopes := range requirement {
+			auth, found := credentials[name]
+			scheme, defined := doc.Components.SecuritySchemes[name]
+			if !found || !defined {
+				continue requirements
+			}
+			auths = append(auths, auth.WithScheme(*scheme, scopes))
+		}
+		if len(auths) == 0 {
+			return Auths{{Type: AuthTypeNone}}, true
+		}
+		return auths, true
+	}
+	return nil, false
+}
+
+// applySecurity sets the credentials of the contract from the security requirements of the operation.
+func (c *Contract) applySecurity(doc *openapi.Document, operation openapi.Operation, credentials map[string]Auth) {
+	auths, ok := findSecurityAuths(doc, operation, credentials)
+	if !ok {
+		fmt.Printf("[%s] Missing credentials for the security requirements of operation %s\n", aurora.Yellow("WARN"), operation.OperationId)
+		return
+	}
+	if auths != nil {
+		c.Auth = auths
+	}
+}
+
+// invalidCredential is used as password, token and API key of credentials which have to be rejected.
+const invalidCredential = "contest-invalid-credential"
+
+// securedOperation checks if the operation requires credentials. An empty security requirement makes them optional.
+func securedOperation(doc *openapi.Document, operation openapi.Operation) bool {
+	requirements := doc.FindSecurity(operation)
+	for _, requirement := range requirements {
+		if len(requirement) == 0 {
+			return false
+		}
+	}
+	return len(requirements) > 0
+}
+
+// invalidAuths returns credentials for the first security requirement of an operation which the server has to reject.
+// Schemes for which no invalid credentials can be created, e.g. HTTP digest, are left out.
+func invalidAuths(doc *openapi.Document, operation openapi.Operation) Auths {
+	requirements := doc.FindSecurity(operation)
+	if len(requirements) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(requirements[0]))
+	for name := range requirements[0] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	auths := make(Auths, 0, len(names))
+	for _, name := range names {
+		scheme, found := doc.Components.SecuritySchemes[name]
+		if !found {
+			continue
+		}
+		switch {
+		case scheme.Type == openapi.SecuritySchemeTypeApiKey:
+			auths = append(auths, Auth{Type: AuthTypeApiKey, Name: scheme.Name, In: scheme.In, Value: invalidCredential})
+		case scheme.Type == openapi.SecuritySchemeTypeHttp && strings.EqualFold(scheme.Scheme, "basic"):
+			auths = append(auths, Auth{Type: AuthTypeBasic, Username: "contest", Password: invalidCredential})
+		case scheme.Type == openapi.SecuritySchemeTypeHttp && strings.EqualFold(scheme.Scheme, "bearer"),
+			scheme.Type == openapi.SecuritySchemeTypeOAuth2,
+			scheme.Type == openapi.SecuritySchemeTypeOpenIdConnect:
+			auths = append(auths, Auth{Type: AuthTypeBearer, Token: invalidCredential})
+		}
+	}
+	return auths
+}
+
+// NewSecurityContracts creates contracts which call a secured operation without credentials and with invalid ones. The
+// contracts expect the status 401 or 403 and the error schema of the operation, if one is documented for that status.
+// Credentials set in headers or parameters, e.g. of the suite, are not sent by these contracts.
+func NewSecurityContracts(url string, method string, doc *openapi.Document, operation openapi.Operation) []*Contract {
+	if !securedOperation(doc, operation) {
+		return nil
+	}
+
+	strip := credentialNames(doc, operation)
+	contracts := []*Contract{newSecurityContract(url, method, operation, "none", Auths{{Type: AuthTypeNone}}, strip)}
+	if auths := invalidAuths(doc, operation); len(auths) > 0 {
+		contracts = append(contracts, newSecurityContract(url, method, operation, "invalid", auths, strip))
+	}
+	return contracts
+}
+
+// credentialNames returns the names of the headers and query parameters which can carry credentials for an operation:
+// Authorization, Cookie and the API keys of its security schemes.
+func credentialNames(doc *openapi.Document, operation openapi.Operation) []string {
+	names := []string{"Authorization", "Cookie"}
+	for _, requirement := range doc.FindSecurity(operation) {
+		for name := range requirement {
+			scheme, found := doc.Components.SecuritySchemes[name]
+			if found && scheme.Type == openapi.SecuritySchemeTypeApiKey && scheme.In != openapi.ParameterInCookie {
+				names = append(names, scheme.Name)
+			}
+		}
+	}
+	sort.Strings(names[2:])
+	return names
+}
+
+func newSecurityContract(url string, method string, operation openapi.Operation, kind string, auths Auths, strip []string) *Contract {
+	name := fmt.Sprintf("%s[security:%s]", operation.OperationId, kind)
+	contract := &Contract{
+		Url:              url,
+		Method:           method,
+		Name:             name,
+		Auth:             auths,
+		Parameters:       make(map[string]string, 0),
+		StripCredentials: strip,
+	}
+
+	for _, statusCode := range []int{401, 403} {
+		subcontract := &Contract{
+			Url:        url,
+			Method:     method,
+			Expect:     Expect{Status: statusCode},
+			Name:       fmt.Sprintf("%s[response:%d]", name, statusCode),
+			Parameters: make(map[string]string, 0),
+		}
+
+		if key, found := FindResponseKey(operation.Responses, statusCode); found {
+			content := operation.Responses[key].Content
+			if mediaType, found := errorMediaType(content); found {
+				subcontract.expectMediaType(mediaType, content[mediaType])
+			}
+		}
+		contract.AnyOf = append(contract.AnyOf, subcontract)
+	}
+	return contract
+}
+
+// errorMediaType selects the media type of an error response to validate. JSON media types are preferred.
+func errorMediaType(content map[string]openapi.MediaType) (string, bool) {
+	mediaTypes := make([]string, 0, len(content))
+	for mediaType := range content {
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	if len(mediaTypes) == 0 {
+		return "", false
+	}
+	sort.Strings(mediaTypes)
+
+	for _, mediaType := range mediaTypes {
+		if openapi.IsJsonMediaType(mediaType) {
+			return mediaType, true
+		}
+	}
+	return mediaTypes[0], true
+}
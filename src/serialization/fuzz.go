@@ -0,0 +1,89 @@
+package serialization
+
+import (
+	"contract-testing/src/serialization/openapi"
+	"fmt"
+	"sort"
+)
+
+// FuzzTarget is an operation of a spec file whose parameters and request body are fuzzed.
+type FuzzTarget struct {
+	Operation  openapi.Operation
+	Parameters []*openapi.Parameter
+	// BodySchema is the schema of the request body for the BodyType of the Contract, or nil if there is none
+	BodySchema *openapi.Schema
+	// Contract is sent with the fuzzed inputs. Its parameters and body are the values from the spec file, which are
+	// used as valid inputs.
+	Contract Contract
+}
+
+// CreateFuzzTargets creates the fuzz targets for the operations of the spec file, ordered by their id. Requests are
+// only sent to the first server of an operation.
+func (s SpecFile) CreateFuzzTargets() ([]FuzzTarget, error) {
+	doc, err := openapi.LoadDocument(s.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	operationIds := s.sortedOperationIds()
+	targets := make([]FuzzTarget, 0, len(operationIds))
+	for _, operationId := range operationIds {
+		sop := s.Operations[operationId]
+		url, method, op, found := doc.FindOperationById(operationId)
+		if !found {
+			return nil, fmt.Errorf("operation %s not found", operationId)
+		}
+
+		baseUrls, err := s.findBaseUrls(doc.FindServers(url, *op))
+		if err != nil {
+			return nil, fmt.Errorf("operation %s: %s", operationId, err)
+		}
+
+		contract := Contract{
+			Url:        baseUrls[0] + url,
+			Method:     method,
+			Name:       operationId,
+			Parameters: deepCopyStringMap(sop.Parameters),
+			Body:       sop.Body,
+			BodyType:   sop.BodyType,
+		}
+		if contract.Parameters == nil {
+			contract.Parameters = make(map[string]string)
+		}
+		if contract.BodyType == "" && op.RequestBody != nil {
+			contract.inferBodyType(op.RequestBody.Content)
+		}
+		contract.applySecurity(doc, *op, s.Auth)
+
+		target := FuzzTarget{
+			Operation:  *op,
+			Parameters: doc.Paths[url].MergedParameters(*op),
+			Contract:   contract,
+		}
+		if op.RequestBody != nil {
+			target.BodySchema = requestBodySchema(op.RequestBody.Content, contract.BodyType)
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// requestBodySchema returns the schema of the first media type of a request body that is encoded with the body type.
+func requestBodySchema(content map[string]openapi.MediaType, bodyType BodyType) *openapi.Schema {
+	mediaTypes := make([]string, 0, len(content))
+	for mediaType := range content {
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	sort.Strings(mediaTypes)
+
+	for _, mediaType := range mediaTypes {
+		base := openapi.BaseMediaType(mediaType)
+		switch {
+		case (bodyType == "" || bodyType == BodyTypeJson) && openapi.IsJsonMediaType(mediaType),
+			bodyType == BodyTypeForm && base == "application/x-www-form-urlencoded",
+			bodyType == BodyTypeMultipart && base == "multipart/form-data":
+			return content[mediaType].Schema
+		}
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package serialization
+
+import (
+	"contract-testing/src/serialization/openapi"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// HarFile describes traffic recorded in the HTTP Archive (HAR) format, e.g. by a browser or a proxy. The recorded
+// responses are validated against the operations of an OpenAPI document without sending any request.
+type HarFile struct {
+	Path string `yaml:"path"`
+	// Spec is the path of the OpenAPI document
+	Spec string `yaml:"spec"`
+	// BaseUrl is the URL the paths of the OpenAPI document are relative to. By default, the servers of the document
+	// are used. Entries with other URLs, e.g. for assets, are skipped.
+	BaseUrl string `yaml:"baseUrl"`
+}
+
+// RecordedResponse is a response from recorded traffic, which is validated instead of sending a request.
+type RecordedResponse struct {
+	StatusCode   int
+	ContentType  string
+	Body         []byte
+	ResponseTime int64
+	// Error describes why the response can't be validated, e.g. because no operation matches its request
+	Error string
+}
+
+type harArchive struct {
+	Log struct {
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	Time    float64 `json:"time"`
+	Request struct {
+		Method string `json:"method"`
+		Url    string `json:"url"`
+	} `json:"request"`
+	Response struct {
+		Status  int `json:"status"`
+		Headers []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"headers"`
+		Content struct {
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+			Encoding string `json:"encoding"`
+		} `json:"content"`
+	} `json:"response"`
+}
+
+// recordedResponse converts the response of the entry. The content type is taken from the headers, because the
+// mimeType of the content may be normalized by the recording tool.
+func (e harEntry) recordedResponse() *RecordedResponse {
+	recorded := &RecordedResponse{
+		StatusCode:   e.Response.Status,
+		ContentType:  e.Response.Content.MimeType,
+		Body:         []byte(e.Response.Content.Text),
+		ResponseTime: int64(e.Time),
+	}
+	for _, header := range e.Response.Headers {
+		if strings.EqualFold(header.Name, "Content-Type") {
+			recorded.ContentType = header.Value
+		}
+	}
+
+	if e.Response.Content.Encoding == "base64" {
+		body, err := base64.StdEncoding.DecodeString(e.Response.Content.Text)
+		if err != nil {
+			recorded.Error = "invalid base64 content: " + err.Error()
+		}
+		recorded.Body = body
+	}
+	return recorded
+}
+
+// CreateContracts creates a contract for every entry of the HAR file whose URL is below a base URL. The contract
+// expects the responses of the operation matching the method and templated path of the entry.
+func (h HarFile) CreateContracts() ([]Contract, error) {
+	doc, err := openapi.LoadDocument(h.Spec)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := ioutil.ReadFile(h.Path)
+	if err != nil {
+		return nil, err
+	}
+	archive := harArchive{}
+	if err = json.Unmarshal(content, &archive); err != nil {
+		return nil, fmt.Errorf("invalid HAR file %s: %s", h.Path, err)
+	}
+
+	baseUrls := h.baseUrls(doc)
+	contracts := make([]Contract, 0, len(archive.Log.Entries))
+	for i, entry := range archive.Log.Entries {
+		// Requests that failed or were blocked have no response
+		if entry.Response.Status == 0 {
+			continue
+		}
+
+		path, found := trimBaseUrls(entry.Request.Url, baseUrls)
+		if !found {
+			continue
+		}
+
+		method := strings.ToUpper(entry.Request.Method)
+		recorded := entry.recordedResponse()
+		url, op, _, found := doc.FindOperation(method, path)
+		if !found {
+			recorded.Error = fmt.Sprintf("no operation matches %s %s", method, path)
+			contracts = append(contracts, Contract{
+				Url:      entry.Request.Url,
+				Method:   method,
+				Name:     fmt.Sprintf("har[%d]", i),
+				Recorded: recorded,
+			})
+			continue
+		}
+
+		operation := *op
+		if operation.OperationId == "" {
+			operation.OperationId = method + " " + url
+		}
+		contract, err := NewContractFromOperation(entry.Request.Url, method, operation)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %s", i, err)
+		}
+		contract.UpdateName(fmt.Sprintf("%s[har:%d]", operation.OperationId, i))
+		contract.Recorded = recorded
+		contract.Tags = operation.Tags
+		contract.copyAttributesToChildren()
+		contracts = append(contracts, *contract)
+	}
+	return contracts, nil
+}
+
+// baseUrls returns the base URL of the HAR file or the URLs of the servers of the document. Without either, all
+// entries are matched against the paths of the document.
+func (h HarFile) baseUrls(doc *openapi.Document) []string {
+	if h.BaseUrl != "" {
+		return []string{h.BaseUrl}
+	}
+
+	baseUrls := make([]string, 0, len(doc.Servers))
+	for _, server := range doc.Servers {
+		if baseUrl, err := server.ResolveUrl(nil); err == nil {
+			baseUrls = append(baseUrls, baseUrl)
+		}
+	}
+	if len(baseUrls) == 0 {
+		baseUrls = append(baseUrls, "/")
+	}
+	return baseUrls
+}
+
+func trimBaseUrls(requestUrl string, baseUrls []string) (string, bool) {
+	for _, baseUrl := range baseUrls {
+		if path, found := openapi.TrimBaseUrl(requestUrl, baseUrl); found {
+			return path, true
+		}
+	}
+	return "", false
+}
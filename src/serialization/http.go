@@ -0,0 +1,69 @@
+package serialization
+
+// HttpConfig configures the HTTP client used for the requests of contracts. It can be set on the suite and overridden
+// per contract. Unset values are taken from the suite or the defaults.
+type HttpConfig struct {
+	// ConnectTimeout is the maximum time in ms to establish a connection
+	ConnectTimeout *int64 `yaml:"connectTimeout"`
+	// Timeout is the maximum time in ms for the whole request including reading the response body
+	Timeout *int64 `yaml:"timeout"`
+
+	FollowRedirects *bool `yaml:"followRedirects"`
+	MaxRedirects    *int  `yaml:"maxRedirects"`
+
+	// CaFile is the path to a PEM file with additional certificate authorities to trust
+	CaFile string `yaml:"caFile"`
+	// ClientCert and ClientKey are paths to the PEM files of a client certificate for mutual TLS
+	ClientCert         string `yaml:"clientCert"`
+	ClientKey          string `yaml:"clientKey"`
+	InsecureSkipVerify *bool  `yaml:"insecureSkipVerify"`
+
+	Http2 *bool  `yaml:"http2"`
+	Proxy string `yaml:"proxy"`
+
+	// Retries is the number of times a request is sent again if it failed without a response, e.g. due to a timeout
+	Retries *int `yaml:"retries"`
+	// RetryDelay is the time in ms to wait before sending a failed request again
+	RetryDelay *int64 `yaml:"retryDelay"`
+}
+
+// Merge returns a copy of the config in which all values set in override are replaced.
+func (c HttpConfig) Merge(override HttpConfig) HttpConfig {
+	if override.ConnectTimeout != nil {
+		c.ConnectTimeout = override.ConnectTimeout
+	}
+	if override.Timeout != nil {
+		c.Timeout = override.Timeout
+	}
+	if override.FollowRedirects != nil {
+		c.FollowRedirects = override.FollowRedirects
+	}
+	if override.MaxRedirects != nil {
+		c.MaxRedirects = override.MaxRedirects
+	}
+	if override.CaFile != "" {
+		c.CaFile = override.CaFile
+	}
+	if override.ClientCert != "" {
+		c.ClientCert = override.ClientCert
+	}
+	if override.ClientKey != "" {
+		c.ClientKey = override.ClientKey
+	}
+	if override.InsecureSkipVerify != nil {
+		c.InsecureSkipVerify = override.InsecureSkipVerify
+	}
+	if override.Http2 != nil {
+		c.Http2 = override.Http2
+	}
+	if override.Proxy != "" {
+		c.Proxy = override.Proxy
+	}
+	if override.Retries != nil {
+		c.Retries = override.Retries
+	}
+	if override.RetryDelay != nil {
+		c.RetryDelay = override.RetryDelay
+	}
+	return c
+}
@@ -0,0 +1,104 @@
+package serialization
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// knownFailureDateLayout is the layout of the expiry date of a known failure.
+const knownFailureDateLayout = "2006-01-02"
+
+// KnownFailure marks failures of a contract, of a reason or with a comment as known issue until it expires. At least
+// one of Contract, Reason and Match has to be set.
+type KnownFailure struct {
+	// Contract is the name of the contract whose failures are known
+	Contract string `yaml:"contract"`
+	Reason   string `yaml:"reason"`
+	// Match is a regular expression the comment of the failure has to contain a match of
+	Match string `yaml:"match"`
+	// Ticket references the issue tracking the failure
+	Ticket string `yaml:"ticket"`
+	// Expires is the last day on which the failure is known, e.g. 2024-12-31
+	Expires string `yaml:"expires"`
+
+	match   *regexp.Regexp
+	expires time.Time
+}
+
+type KnownFailures []KnownFailure
+
+func (k *KnownFailures) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var knownFailures []KnownFailure
+	if err := unmarshal(&knownFailures); err != nil {
+		return err
+	}
+	for i := range knownFailures {
+		if err := knownFailures[i].compile(); err != nil {
+			return err
+		}
+	}
+	*k = knownFailures
+	return nil
+}
+
+// compile checks the known failure, compiles its match and parses its expiry date.
+func (k *KnownFailure) compile() error {
+	if k.Ticket == "" {
+		return fmt.Errorf("known failure without ticket")
+	}
+	if k.Contract == "" && k.Reason == "" && k.Match == "" {
+		return fmt.Errorf("known failure %s has no contract, reason or match", k.Ticket)
+	}
+	if k.Reason != "" && !containsReason(k.Reason) {
+		return fmt.Errorf("unknown failure reason %q in known failure %s", k.Reason, k.Ticket)
+	}
+
+	expires, err := time.ParseInLocation(knownFailureDateLayout, k.Expires, time.Local)
+	if err != nil {
+		return fmt.Errorf("invalid expiry date %q of known failure %s, expected e.g. 2024-12-31", k.Expires, k.Ticket)
+	}
+	k.expires = expires
+
+	if k.Match != "" {
+		match, err := regexp.Compile(k.Match)
+		if err != nil {
+			return fmt.Errorf("invalid match %q of known failure %s: %w", k.Match, k.Ticket, err)
+		}
+		k.match = match
+	}
+	return nil
+}
+
+// Expired checks if the day of the expiry date is over at the given time.
+func (k KnownFailure) Expired(now time.Time) bool {
+	expires := k.expires
+	if expires.IsZero() {
+		parsed, err := time.ParseInLocation(knownFailureDateLayout, k.Expires, time.Local)
+		if err != nil {
+			return true
+		}
+		expires = parsed
+	}
+	return !now.Before(expires.AddDate(0, 0, 1))
+}
+
+// AppliesTo checks if the known failure can match failures of the contract with the name.
+func (k KnownFailure) AppliesTo(contract string) bool {
+	return k.Contract == "" || k.Contract == contract
+}
+
+// Matches checks if a failure with the reason and comment of the contract with the name is known.
+func (k KnownFailure) Matches(contract string, reason string, comment string) bool {
+	if !k.AppliesTo(contract) || (k.Reason != "" && k.Reason != reason) {
+		return false
+	}
+	if k.Match == "" {
+		return true
+	}
+	if k.match != nil {
+		return k.match.MatchString(comment)
+	}
+	matched, err := regexp.MatchString(k.Match, comment)
+	return err == nil && matched
+}
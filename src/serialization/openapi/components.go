@@ -1,9 +1,11 @@
 package openapi
 
 type Components struct {
-	Schemas    map[string]*Schema   `yaml:"schemas"`
-	Parameters map[string]Parameter `yaml:"parameters"`
-	Responses  map[string]Response  `yaml:"responses"`
+	Schemas    map[string]*Schema    `yaml:"schemas"`
+	Parameters map[string]*Parameter `yaml:"parameters"`
+	Responses  map[string]*Response  `yaml:"responses"`
+
+	SecuritySchemes map[string]*SecurityScheme `yaml:"securitySchemes"`
 }
 
 type SchemaType string
@@ -33,6 +35,21 @@ type Schema struct {
 	Items       *Schema            `yaml:"items"`
 	Format      SchemaFormat       `yaml:"format"`
 
+	// Enum, Example and the constraints below are used to generate values when fuzzing, they are not validated yet
+	Enum             []interface{} `yaml:"enum"`
+	Example          interface{}   `yaml:"example"`
+	Minimum          *float64      `yaml:"minimum"`
+	Maximum          *float64      `yaml:"maximum"`
+	ExclusiveMinimum bool          `yaml:"exclusiveMinimum"`
+	ExclusiveMaximum bool          `yaml:"exclusiveMaximum"`
+	MinLength        *int          `yaml:"minLength"`
+	MaxLength        *int          `yaml:"maxLength"`
+	MinItems         *int          `yaml:"minItems"`
+	MaxItems         *int          `yaml:"maxItems"`
+
+	AdditionalProperties *AdditionalProperties `yaml:"additionalProperties"`
+	Xml                  *Xml                  `yaml:"xml"`
+
 	AnyOf []*Schema `yaml:"anyOf"`
 	OneOf []*Schema `yaml:"oneOf"`
 	AllOf []*Schema `yaml:"allOf"`
@@ -40,6 +57,34 @@ type Schema struct {
 	Ref string `yaml:"$ref"`
 }
 
+// AdditionalProperties is either a boolean that allows or forbids properties not listed in Schema.Properties, or a
+// Schema that all of those properties must match.
+type AdditionalProperties struct {
+	Allowed bool
+	Schema  *Schema
+}
+
+func (a *AdditionalProperties) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var allowed bool
+	if err := unmarshal(&allowed); err == nil {
+		a.Allowed = allowed
+		return nil
+	}
+
+	a.Allowed = true
+	a.Schema = &Schema{}
+	return unmarshal(a.Schema)
+}
+
+// Xml describes how a Schema is represented in XML.
+type Xml struct {
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace"`
+	Prefix    string `yaml:"prefix"`
+	Attribute bool   `yaml:"attribute"`
+	Wrapped   bool   `yaml:"wrapped"`
+}
+
 func (s Schema) Requires(key string) bool {
 	for _, val := range s.Required {
 		if val == key {
@@ -49,6 +94,34 @@ func (s Schema) Requires(key string) bool {
 	return false
 }
 
+// AllProperties returns the properties of the schema including those of its allOf subschemas, and which of them are
+// required.
+func (s *Schema) AllProperties() (map[string]*Schema, map[string]bool) {
+	properties := make(map[string]*Schema)
+	required := make(map[string]bool)
+	s.collectProperties(properties, required, make(map[*Schema]bool))
+	return properties, required
+}
+
+// collectProperties adds the properties of the schema and its allOf subschemas. Schemas already visited are skipped,
+// which ends schemas that include themselves through allOf.
+func (s *Schema) collectProperties(properties map[string]*Schema, required map[string]bool, visited map[*Schema]bool) {
+	if s == nil || visited[s] {
+		return
+	}
+	visited[s] = true
+
+	for name, property := range s.Properties {
+		properties[name] = property
+	}
+	for _, name := range s.Required {
+		required[name] = true
+	}
+	for _, subschema := range s.AllOf {
+		subschema.collectProperties(properties, required, visited)
+	}
+}
+
 type ParameterIn string
 
 const (
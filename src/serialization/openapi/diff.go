@@ -0,0 +1,516 @@
+package openapi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type ChangeLevel string
+
+const (
+	ChangeBreaking    ChangeLevel = "breaking"     // The change breaks existing clients
+	ChangeNonBreaking ChangeLevel = "non-breaking" // Existing clients keep working
+)
+
+// Change is a difference between two versions of an OpenAPI document.
+type Change struct {
+	Level ChangeLevel `json:"level"`
+	// Operation is the method and path of the changed operation, e.g. GET /pets/{id}
+	Operation string `json:"operation"`
+	// Location is the part of the operation that changed, e.g. a parameter or a property of a response
+	Location string `json:"location,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (c Change) String() string {
+	if c.Location == "" {
+		return fmt.Sprintf("%s: %s", c.Operation, c.Message)
+	}
+	return fmt.Sprintf("%s %s: %s", c.Operation, c.Location, c.Message)
+}
+
+// schemaDirection is whether a schema describes data sent by clients or received by them. A change that is safe in one
+// direction usually breaks clients in the other, e.g. removing a property.
+type schemaDirection int
+
+const (
+	directionRequest schemaDirection = iota
+	directionResponse
+)
+
+// levelFor returns ChangeBreaking if a change is made to a schema in the direction in which it breaks clients.
+func levelFor(direction schemaDirection, breaking schemaDirection) ChangeLevel {
+	if direction == breaking {
+		return ChangeBreaking
+	}
+	return ChangeNonBreaking
+}
+
+// comparison identifies the comparison of two schemas at a location of an operation. A schema shared by several
+// operations or locations, e.g. a component used by a request and a response, is compared at each of them.
+type comparison struct {
+	old       *Schema
+	new       *Schema
+	operation string
+	location  string
+	direction schemaDirection
+}
+
+// differ collects the changes between two documents.
+type differ struct {
+	changes   []Change
+	operation string
+	// visited contains the comparisons already made, which ends the comparison of recursive schemas
+	visited map[comparison]bool
+}
+
+func (d *differ) add(level ChangeLevel, location string, format string, args ...interface{}) {
+	d.changes = append(d.changes, Change{
+		Level:     level,
+		Operation: d.operation,
+		Location:  location,
+		Message:   fmt.Sprintf(format, args...),
+	})
+}
+
+// Diff compares two versions of an OpenAPI document and returns the changes of their operations, ordered by path and
+// method. Paths which only differ in the names of their path parameters, e.g. /pets/{id} and /pets/{petId}, are the
+// same path, and the path parameters are compared by position.
+func Diff(old *Document, new *Document) []Change {
+	d := &differ{changes: make([]Change, 0), visited: make(map[comparison]bool)}
+
+	oldUrls, newUrls := pathTemplates(old.Paths), pathTemplates(new.Paths)
+	for _, key := range unionPathKeys(oldUrls, newUrls) {
+		oldUrl, inOld := oldUrls[key]
+		newUrl, inNew := newUrls[key]
+		oldPath, newPath := old.Paths[oldUrl], new.Paths[newUrl]
+		url := newUrl
+		if !inNew {
+			url = oldUrl
+		}
+
+		for _, method := range unionMethods(oldPath.Operations, newPath.Operations) {
+			oldOperation, oldFound := oldPath.Operations[method]
+			newOperation, newFound := newPath.Operations[method]
+			d.operation = strings.ToUpper(method) + " " + url
+
+			switch {
+			case !inNew || !newFound:
+				d.add(ChangeBreaking, "", "operation removed")
+			case !inOld || !oldFound:
+				d.add(ChangeNonBreaking, "", "operation added")
+			default:
+				oldParameters := renamePathParameters(oldPath.MergedParameters(oldOperation), oldUrl, newUrl)
+				d.parameters(oldParameters, newPath.MergedParameters(newOperation))
+				d.requestBody(oldOperation.RequestBody, newOperation.RequestBody)
+				d.responses(oldOperation.Responses, newOperation.Responses)
+			}
+		}
+	}
+	return d.changes
+}
+
+func (d *differ) parameters(old []*Parameter, new []*Parameter) {
+	for _, oldParameter := range old {
+		location := fmt.Sprintf("parameter %s:%s", oldParameter.In, oldParameter.Name)
+		newParameter := findParameter(new, oldParameter.Name, oldParameter.In)
+		if newParameter == nil {
+			d.add(ChangeNonBreaking, location, "removed")
+			continue
+		}
+		if newParameter.Required && !oldParameter.Required {
+			d.add(ChangeBreaking, location, "became required")
+		}
+		d.schema(oldParameter.Schema, newParameter.Schema, location, "", directionRequest)
+	}
+
+	for _, newParameter := range new {
+		if findParameter(old, newParameter.Name, newParameter.In) != nil {
+			continue
+		}
+		location := fmt.Sprintf("parameter %s:%s", newParameter.In, newParameter.Name)
+		if newParameter.Required {
+			d.add(ChangeBreaking, location, "added as required")
+		} else {
+			d.add(ChangeNonBreaking, location, "added")
+		}
+	}
+}
+
+func (d *differ) requestBody(old *RequestBody, new *RequestBody) {
+	switch {
+	case old == nil && new == nil:
+		return
+	case old == nil:
+		if new.Required {
+			d.add(ChangeBreaking, "request body", "added as required")
+		} else {
+			d.add(ChangeNonBreaking, "request body", "added")
+		}
+		return
+	case new == nil:
+		d.add(ChangeNonBreaking, "request body", "removed")
+		return
+	}
+
+	if new.Required && !old.Required {
+		d.add(ChangeBreaking, "request body", "became required")
+	}
+	for _, mediaType := range sortedMediaTypes(old.Content) {
+		location := "request body " + mediaType
+		newContent, found := new.Content[mediaType]
+		if !found {
+			d.add(ChangeBreaking, location, "media type removed")
+			continue
+		}
+		d.schema(old.Content[mediaType].Schema, newContent.Schema, location, "", directionRequest)
+	}
+	for _, mediaType := range sortedMediaTypes(new.Content) {
+		if _, found := old.Content[mediaType]; !found {
+			d.add(ChangeNonBreaking, "request body "+mediaType, "media type added")
+		}
+	}
+}
+
+func (d *differ) responses(old map[string]*Response, new map[string]*Response) {
+	for _, status := range sortedStatusCodes(old) {
+		location := "response " + status
+		newResponse, found := new[status]
+		if !found {
+			d.add(ChangeBreaking, location, "removed")
+			continue
+		}
+
+		oldContent := old[status].Content
+		for _, mediaType := range sortedMediaTypes(oldContent) {
+			newContent, found := newResponse.Content[mediaType]
+			if !found {
+				d.add(ChangeBreaking, location+" "+mediaType, "media type removed")
+				continue
+			}
+			d.schema(oldContent[mediaType].Schema, newContent.Schema, location+" "+mediaType, "", directionResponse)
+		}
+		for _, mediaType := range sortedMediaTypes(newResponse.Content) {
+			if _, found := oldContent[mediaType]; !found {
+				d.add(ChangeNonBreaking, location+" "+mediaType, "media type added")
+			}
+		}
+	}
+
+	for _, status := range sortedStatusCodes(new) {
+		if _, found := old[status]; !found {
+			d.add(ChangeNonBreaking, "response "+status, "added")
+		}
+	}
+}
+
+// schema compares two versions of a schema. location is the part of the operation containing the schema and path the
+// path of the schema within it.
+func (d *differ) schema(old *Schema, new *Schema, location string, path string, direction schemaDirection) {
+	if old == nil || new == nil {
+		if old != nil {
+			d.add(levelFor(direction, directionResponse), schemaLocation(location, path), "schema removed")
+		}
+		return
+	}
+	key := comparison{old: old, new: new, operation: d.operation, location: location, direction: direction}
+	if d.visited[key] {
+		return
+	}
+	d.visited[key] = true
+	at := schemaLocation(location, path)
+
+	if old.Type != "" && new.Type == "" {
+		d.add(levelFor(direction, directionResponse), at, "type %s removed", old.Type)
+	} else if old.Type != new.Type && old.Type != "" {
+		// Every integer is a number, so clients can still send integers
+		if direction == directionRequest && old.Type == SchemaTypeInteger && new.Type == SchemaTypeNumber {
+			d.add(ChangeNonBreaking, at, "type changed from %s to %s", old.Type, new.Type)
+		} else {
+			d.add(ChangeBreaking, at, "type changed from %s to %s", old.Type, new.Type)
+		}
+		return
+	}
+	if old.Type == "" && new.Type != "" {
+		d.add(levelFor(direction, directionRequest), at, "type restricted to %s", new.Type)
+	}
+
+	d.nullable(old, new, at, direction)
+	d.enum(old, new, at, direction)
+	if direction == directionRequest {
+		d.constraints(old, new, at)
+	}
+	d.properties(old, new, location, path, direction)
+
+	if old.Items != nil || new.Items != nil {
+		d.schema(old.Items, new.Items, location, path+"[]", direction)
+	}
+	for i := 0; i < len(old.AnyOf) && i < len(new.AnyOf); i++ {
+		d.schema(old.AnyOf[i], new.AnyOf[i], location, fmt.Sprintf("%s(anyOf %d)", path, i), direction)
+	}
+	for i := 0; i < len(old.OneOf) && i < len(new.OneOf); i++ {
+		d.schema(old.OneOf[i], new.OneOf[i], location, fmt.Sprintf("%s(oneOf %d)", path, i), direction)
+	}
+}
+
+func (d *differ) nullable(old *Schema, new *Schema, at string, direction schemaDirection) {
+	if old.Nullable == new.Nullable {
+		return
+	}
+	if (direction == directionRequest) == new.Nullable {
+		d.add(ChangeNonBreaking, at, "nullable changed to %t", new.Nullable)
+	} else {
+		d.add(ChangeBreaking, at, "nullable changed to %t", new.Nullable)
+	}
+}
+
+// enum reports removed enum values of requests and added enum values of responses as breaking, because clients send
+// or receive values the other side doesn't know.
+func (d *differ) enum(old *Schema, new *Schema, at string, direction schemaDirection) {
+	if len(old.Enum) == 0 && len(new.Enum) > 0 {
+		d.add(levelFor(direction, directionRequest), at, "restricted to enum %s", joinValues(new.Enum))
+		return
+	}
+	if len(old.Enum) == 0 || len(new.Enum) == 0 {
+		return
+	}
+
+	removed := subtractValues(old.Enum, new.Enum)
+	added := subtractValues(new.Enum, old.Enum)
+	if len(removed) > 0 {
+		d.add(levelFor(direction, directionRequest), at, "enum values removed: %s", joinValues(removed))
+	}
+	if len(added) > 0 {
+		d.add(levelFor(direction, directionResponse), at, "enum values added: %s", joinValues(added))
+	}
+}
+
+// constraints reports narrowed constraints of request values, which reject values that were valid before.
+func (d *differ) constraints(old *Schema, new *Schema, at string) {
+	if narrowedInt(old.MaxLength, new.MaxLength, false) {
+		d.add(ChangeBreaking, at, "maxLength narrowed to %d", *new.MaxLength)
+	}
+	if narrowedInt(old.MinLength, new.MinLength, true) {
+		d.add(ChangeBreaking, at, "minLength narrowed to %d", *new.MinLength)
+	}
+	if narrowedFloat(old.Maximum, new.Maximum, false) {
+		d.add(ChangeBreaking, at, "maximum narrowed to %v", *new.Maximum)
+	}
+	if narrowedFloat(old.Minimum, new.Minimum, true) {
+		d.add(ChangeBreaking, at, "minimum narrowed to %v", *new.Minimum)
+	}
+}
+
+func (d *differ) properties(old *Schema, new *Schema, location string, path string, direction schemaDirection) {
+	oldProperties, oldRequired := old.AllProperties()
+	newProperties, newRequired := new.AllProperties()
+
+	for _, name := range sortedPropertyNames(oldProperties) {
+		at := schemaLocation(location, joinSchemaPath(path, name))
+		newProperty, found := newProperties[name]
+		if !found {
+			d.add(levelFor(direction, directionResponse), at, "property removed")
+			continue
+		}
+
+		if direction == directionRequest && newRequired[name] && !oldRequired[name] {
+			d.add(ChangeBreaking, at, "became required")
+		} else if direction == directionResponse && oldRequired[name] && !newRequired[name] {
+			d.add(ChangeBreaking, at, "became optional")
+		}
+		d.schema(oldProperties[name], newProperty, location, joinSchemaPath(path, name), direction)
+	}
+
+	for _, name := range sortedPropertyNames(newProperties) {
+		if _, found := oldProperties[name]; found {
+			continue
+		}
+		at := schemaLocation(location, joinSchemaPath(path, name))
+		if direction == directionRequest && newRequired[name] {
+			d.add(ChangeBreaking, at, "property added as required")
+		} else {
+			d.add(ChangeNonBreaking, at, "property added")
+		}
+	}
+}
+
+func findParameter(parameters []*Parameter, name string, in ParameterIn) *Parameter {
+	for _, parameter := range parameters {
+		if parameter.Name == name && parameter.In == in {
+			return parameter
+		}
+	}
+	return nil
+}
+
+// narrowedInt checks if a minimum (isMin) or maximum constraint was added or narrowed.
+func narrowedInt(old *int, new *int, isMin bool) bool {
+	if new == nil {
+		return false
+	}
+	if old == nil {
+		return true
+	}
+	return (isMin && *new > *old) || (!isMin && *new < *old)
+}
+
+func narrowedFloat(old *float64, new *float64, isMin bool) bool {
+	if new == nil {
+		return false
+	}
+	if old == nil {
+		return true
+	}
+	return (isMin && *new > *old) || (!isMin && *new < *old)
+}
+
+func subtractValues(values []interface{}, subtrahend []interface{}) []interface{} {
+	remaining := make([]interface{}, 0)
+outer:
+	for _, value := range values {
+		for _, other := range subtrahend {
+			if fmt.Sprint(value) == fmt.Sprint(other) {
+				continue outer
+			}
+		}
+		remaining = append(remaining, value)
+	}
+	return remaining
+}
+
+func joinValues(values []interface{}) string {
+	strs := make([]string, len(values))
+	for i, value := range values {
+		strs[i] = fmt.Sprint(value)
+	}
+	return strings.Join(strs, ", ")
+}
+
+func schemaLocation(location string, path string) string {
+	if path == "" {
+		return location
+	}
+	return location + ": " + path
+}
+
+func joinSchemaPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// pathKey returns the URL of a path without the names of its path parameters, e.g. /pets/{} for /pets/{id}.
+func pathKey(url string) string {
+	return pathTemplatePattern.ReplaceAllString(url, "{}")
+}
+
+// pathTemplates returns the URLs of the paths by their pathKey. Of several URLs with the same key, the first in sort
+// order is used.
+func pathTemplates(paths map[string]Path) map[string]string {
+	urls := make([]string, 0, len(paths))
+	for url := range paths {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+
+	templates := make(map[string]string, len(urls))
+	for _, url := range urls {
+		if _, found := templates[pathKey(url)]; !found {
+			templates[pathKey(url)] = url
+		}
+	}
+	return templates
+}
+
+func unionPathKeys(a map[string]string, b map[string]string) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, found := a[key]; !found {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return pathUrl(a, b, keys[i]) < pathUrl(a, b, keys[j])
+	})
+	return keys
+}
+
+// pathUrl returns the URL of a path key in the new document, or in the old one if the path was removed.
+func pathUrl(old map[string]string, new map[string]string, key string) string {
+	if url, found := new[key]; found {
+		return url
+	}
+	return old[key]
+}
+
+// renamePathParameters returns the parameters with the path parameters of oldUrl renamed to the names at the same
+// position in newUrl.
+func renamePathParameters(parameters []*Parameter, oldUrl string, newUrl string) []*Parameter {
+	if oldUrl == newUrl {
+		return parameters
+	}
+
+	oldNames := pathTemplatePattern.FindAllStringSubmatch(oldUrl, -1)
+	newNames := pathTemplatePattern.FindAllStringSubmatch(newUrl, -1)
+	names := make(map[string]string, len(oldNames))
+	for i := range oldNames {
+		names[oldNames[i][1]] = newNames[i][1]
+	}
+
+	renamed := make([]*Parameter, 0, len(parameters))
+	for _, parameter := range parameters {
+		if name, found := names[parameter.Name]; found && parameter.In == ParameterInPath {
+			copied := *parameter
+			copied.Name = name
+			parameter = &copied
+		}
+		renamed = append(renamed, parameter)
+	}
+	return renamed
+}
+
+func unionMethods(a map[string]Operation, b map[string]Operation) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, found := a[key]; !found {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedMediaTypes(content map[string]MediaType) []string {
+	mediaTypes := make([]string, 0, len(content))
+	for mediaType := range content {
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	sort.Strings(mediaTypes)
+	return mediaTypes
+}
+
+func sortedStatusCodes(responses map[string]*Response) []string {
+	statusCodes := make([]string, 0, len(responses))
+	for statusCode := range responses {
+		statusCodes = append(statusCodes, statusCode)
+	}
+	sort.Strings(statusCodes)
+	return statusCodes
+}
+
+func sortedPropertyNames(properties map[string]*Schema) []string {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
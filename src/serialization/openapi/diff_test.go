@@ -0,0 +1,188 @@
+package openapi
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+const diffPetsDocument = `openapi: 3.0.0
+info:
+  title: Pets
+  version: "1"
+paths:
+  /pets:
+    get:
+      responses:
+        "200":
+          description: pets
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: "#/components/schemas/Pet"
+    post:
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/Pet"
+      responses:
+        "201":
+          description: created
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Pet"
+components:
+  schemas:
+    Pet:
+%s
+`
+
+const diffPet = `      type: object
+      required: [name]
+      properties:
+        name:
+          type: string`
+
+func loadDiffDocument(t *testing.T, name string, pet string) *Document {
+	path := filepath.Join(t.TempDir(), name)
+	content := []byte(fmt.Sprintf(diffPetsDocument, pet))
+	if err := ioutil.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	document, err := LoadDocument(path)
+	if err != nil {
+		t.Fatalf("could not load %s: %s", name, err)
+	}
+	return document
+}
+
+func TestDiffSharedComponent(t *testing.T) {
+	tests := []struct {
+		name     string
+		pet      string
+		expected []Change
+	}{
+		{
+			name: "required property added",
+			pet: `      type: object
+      required: [name, tag]
+      properties:
+        name:
+          type: string
+        tag:
+          type: string`,
+			expected: []Change{
+				{ChangeNonBreaking, "GET /pets", "response 200 application/json: [].tag", "property added"},
+				{ChangeBreaking, "POST /pets", "request body application/json: tag", "property added as required"},
+				{ChangeNonBreaking, "POST /pets", "response 201 application/json: tag", "property added"},
+			},
+		},
+		{
+			name: "property removed",
+			pet: `      type: object
+      properties:
+        id:
+          type: integer`,
+			expected: []Change{
+				{ChangeBreaking, "GET /pets", "response 200 application/json: [].name", "property removed"},
+				{ChangeNonBreaking, "POST /pets", "request body application/json: name", "property removed"},
+				{ChangeBreaking, "POST /pets", "response 201 application/json: name", "property removed"},
+				{ChangeNonBreaking, "GET /pets", "response 200 application/json: [].id", "property added"},
+				{ChangeNonBreaking, "POST /pets", "request body application/json: id", "property added"},
+				{ChangeNonBreaking, "POST /pets", "response 201 application/json: id", "property added"},
+			},
+		},
+	}
+
+	old := loadDiffDocument(t, "old.yaml", diffPet)
+	for _, test := range tests {
+		changes := Diff(old, loadDiffDocument(t, "new.yaml", test.pet))
+		for _, expected := range test.expected {
+			if !containsChange(changes, expected) {
+				t.Errorf("%s: missing change %s (%s) in %v", test.name, expected, expected.Level, changes)
+			}
+		}
+		if len(changes) != len(test.expected) {
+			t.Errorf("%s: %d changes instead of %d: %v", test.name, len(changes), len(test.expected), changes)
+		}
+	}
+}
+
+func containsChange(changes []Change, change Change) bool {
+	for _, c := range changes {
+		if c == change {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDiffTypeRemoved(t *testing.T) {
+	pet := `      type: object
+      required: [name]
+      properties:
+        name: {}`
+	changes := Diff(loadDiffDocument(t, "old.yaml", diffPet), loadDiffDocument(t, "new.yaml", pet))
+	expected := []Change{
+		{ChangeBreaking, "GET /pets", "response 200 application/json: [].name", "type string removed"},
+		{ChangeNonBreaking, "POST /pets", "request body application/json: name", "type string removed"},
+		{ChangeBreaking, "POST /pets", "response 201 application/json: name", "type string removed"},
+	}
+	for _, change := range expected {
+		if !containsChange(changes, change) {
+			t.Errorf("missing change %s (%s) in %v", change, change.Level, changes)
+		}
+	}
+	if len(changes) != len(expected) {
+		t.Errorf("%d changes instead of %d: %v", len(changes), len(expected), changes)
+	}
+}
+
+const diffPetDocument = `openapi: 3.0.0
+info:
+  title: Pets
+  version: "1"
+paths:
+  /pets/{%s}:
+    get:
+      parameters:
+        - name: %[1]s
+          in: path
+          required: true
+          schema:
+            type: %s
+      responses:
+        "200":
+          description: pet
+`
+
+func TestDiffPathParameterRenamed(t *testing.T) {
+	load := func(name string, parameter string, parameterType string) *Document {
+		path := filepath.Join(t.TempDir(), name)
+		content := []byte(fmt.Sprintf(diffPetDocument, parameter, parameterType))
+		if err := ioutil.WriteFile(path, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+		document, err := LoadDocument(path)
+		if err != nil {
+			t.Fatalf("could not load %s: %s", name, err)
+		}
+		return document
+	}
+
+	if changes := Diff(load("old.yaml", "id", "string"), load("new.yaml", "petId", "string")); len(changes) != 0 {
+		t.Errorf("renamed path parameter reported as change: %v", changes)
+	}
+
+	changes := Diff(load("old.yaml", "id", "string"), load("new.yaml", "petId", "integer"))
+	expected := Change{ChangeBreaking, "GET /pets/{petId}", "parameter path:petId", "type changed from string to integer"}
+	if len(changes) != 1 || changes[0] != expected {
+		t.Errorf("expected only %s, got %v", expected, changes)
+	}
+}
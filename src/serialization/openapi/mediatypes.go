@@ -0,0 +1,38 @@
+package openapi
+
+import (
+	"strings"
+)
+
+// BaseMediaType returns the media type without parameters (e.g. charset) in lower case.
+func BaseMediaType(mediaType string) string {
+	return strings.ToLower(strings.TrimSpace(strings.SplitN(mediaType, ";", 2)[0]))
+}
+
+// IsJsonMediaType checks if the media type is application/json or has the structured syntax suffix +json, like
+// application/problem+json or application/vnd.example+json.
+func IsJsonMediaType(mediaType string) bool {
+	mediaType = BaseMediaType(mediaType)
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// IsXmlMediaType checks if the media type is application/xml, text/xml or has the structured syntax suffix +xml.
+func IsXmlMediaType(mediaType string) bool {
+	mediaType = BaseMediaType(mediaType)
+	return mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml")
+}
+
+// MediaTypeMatches checks if a media type matches an expected media type, which may be a range like text/* or */*.
+// Parameters of the media types are ignored.
+func MediaTypeMatches(mediaType string, expected string) bool {
+	mediaType = BaseMediaType(mediaType)
+	expected = BaseMediaType(expected)
+
+	if expected == "*/*" || expected == mediaType {
+		return true
+	}
+	if strings.HasSuffix(expected, "/*") {
+		return strings.HasPrefix(mediaType, strings.TrimSuffix(expected, "*"))
+	}
+	return false
+}
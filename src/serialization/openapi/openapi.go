@@ -1,22 +1,69 @@
 package openapi
 
 import (
+	"fmt"
 	"gopkg.in/yaml.v2"
 	"io/ioutil"
 	"path/filepath"
+	"regexp"
 	"strings"
 )
 
 type Document struct {
-	Components Components      `yaml:"components"`
-	Paths      map[string]Path `yaml:"paths"`
-	Servers    []Server        `yaml:"servers"`
+	Components Components            `yaml:"components"`
+	Paths      map[string]Path       `yaml:"paths"`
+	Servers    []Server              `yaml:"servers"`
+	Security   []SecurityRequirement `yaml:"security"`
 
 	AbsolutePath string
 }
 
 type Server struct {
-	Url string `yaml:"url"`
+	Url         string                    `yaml:"url"`
+	Description string                    `yaml:"description"`
+	Variables   map[string]ServerVariable `yaml:"variables"`
+}
+
+type ServerVariable struct {
+	Enum        []string `yaml:"enum"`
+	Default     string   `yaml:"default"`
+	Description string   `yaml:"description"`
+}
+
+var serverVariablePattern = regexp.MustCompile(`{[^{}]*}`)
+
+// ResolveUrl substitutes the variables in the URL template of the server. The value of a variable is taken from values
+// or, if it is not given there, from the default of the variable.
+func (s Server) ResolveUrl(values map[string]string) (string, error) {
+	var err error
+	url := serverVariablePattern.ReplaceAllStringFunc(s.Url, func(match string) string {
+		name := strings.Trim(match, "{}")
+		variable, declared := s.Variables[name]
+
+		value, found := values[name]
+		if !found {
+			if !declared {
+				err = fmt.Errorf("no value for server variable %s in %s", name, s.Url)
+				return match
+			}
+			value = variable.Default
+		}
+
+		if declared && len(variable.Enum) > 0 && !contains(variable.Enum, value) {
+			err = fmt.Errorf("value %s for server variable %s in %s is not one of %s", value, name, s.Url, strings.Join(variable.Enum, ", "))
+		}
+		return value
+	})
+	return url, err
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
 }
 
 func LoadDocument(path string) (*Document, error) {
@@ -68,13 +115,19 @@ func loadDocumentNoResolve(path string) (*Document, error) {
 		}
 
 		for _, op := range path.Operations {
+			for _, parameter := range op.Parameters {
+				if strings.HasPrefix(parameter.Ref, "#") {
+					parameter.Ref = document.AbsolutePath + parameter.Ref
+				}
+			}
+
 			for _, response := range op.Responses {
 				if strings.HasPrefix(response.Ref, "#") {
 					response.Ref = document.AbsolutePath + response.Ref
 				}
 
 				for _, mediaType := range response.Content {
-					if strings.HasPrefix(mediaType.Schema.Ref, "#") {
+					if mediaType.Schema != nil && strings.HasPrefix(mediaType.Schema.Ref, "#") {
 						mediaType.Schema.Ref = document.AbsolutePath + mediaType.Schema.Ref
 					}
 				}
@@ -85,6 +138,18 @@ func loadDocumentNoResolve(path string) (*Document, error) {
 	return &document, err
 }
 
+// FindServers returns the servers of the operation at the given URL. Servers of the operation take precedence over
+// servers of the path, which take precedence over the servers of the document.
+func (document Document) FindServers(url string, operation Operation) []Server {
+	if len(operation.Servers) > 0 {
+		return operation.Servers
+	}
+	if len(document.Paths[url].Servers) > 0 {
+		return document.Paths[url].Servers
+	}
+	return document.Servers
+}
+
 // FindOperationById gets the operation with the given id from a document.
 // It returns the URL, method, Operation and if an operation was found.
 func (document Document) FindOperationById(id string) (string, string, *Operation, bool) {
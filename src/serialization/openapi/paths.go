@@ -1,9 +1,17 @@
 package openapi
 
+import (
+	neturl "net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
 type Path struct {
 	Summary     string               `yaml:"summary"`
 	Description string               `yaml:"description"`
 	Parameters  []*Parameter         `yaml:"parameters"`
+	Servers     []Server             `yaml:"servers"`
 	Operations  map[string]Operation `yaml:",inline"`
 }
 
@@ -12,7 +20,41 @@ type Operation struct {
 	OperationId string               `yaml:"operationId"`
 	Description string               `yaml:"description"`
 	Tags        []string             `yaml:"tags"`
+	Parameters  []*Parameter         `yaml:"parameters"`
+	RequestBody *RequestBody         `yaml:"requestBody"`
 	Responses   map[string]*Response `yaml:"responses"`
+	Servers     []Server             `yaml:"servers"`
+	// Security is nil if the operation doesn't override the security requirements of the document
+	Security []SecurityRequirement `yaml:"security"`
+}
+
+// MergedParameters returns the parameters of the path combined with the parameters of the given operation. An
+// operation parameter overrides a path parameter with the same name and location.
+func (p Path) MergedParameters(operation Operation) []*Parameter {
+	parameters := make([]*Parameter, 0, len(p.Parameters)+len(operation.Parameters))
+	for _, parameter := range p.Parameters {
+		if !operation.hasParameter(parameter.Name, parameter.In) {
+			parameters = append(parameters, parameter)
+		}
+	}
+	return append(parameters, operation.Parameters...)
+}
+
+func (o Operation) hasParameter(name string, in ParameterIn) bool {
+	for _, parameter := range o.Parameters {
+		if parameter.Name == name && parameter.In == in {
+			return true
+		}
+	}
+	return false
+}
+
+type RequestBody struct {
+	Description string               `yaml:"description"`
+	Content     map[string]MediaType `yaml:"content"`
+	Required    bool                 `yaml:"required"`
+
+	Ref string `yaml:"$ref"`
 }
 
 type Response struct {
@@ -25,3 +67,84 @@ type Response struct {
 type MediaType struct {
 	Schema *Schema `yaml:"schema"`
 }
+
+var pathTemplatePattern = regexp.MustCompile(`{([^{}/]+)}`)
+
+// MatchPath matches a path against the templated URL of a path item, e.g. /pets/{id}. It returns the values of the
+// path parameters.
+func MatchPath(template string, path string) (map[string]string, bool) {
+	names := make([]string, 0)
+	pattern := "^"
+	last := 0
+	for _, match := range pathTemplatePattern.FindAllStringSubmatchIndex(template, -1) {
+		pattern += regexp.QuoteMeta(template[last:match[0]]) + "([^/]+)"
+		names = append(names, template[match[2]:match[3]])
+		last = match[1]
+	}
+	pattern += regexp.QuoteMeta(template[last:]) + "$"
+
+	submatches := regexp.MustCompile(pattern).FindStringSubmatch(path)
+	if submatches == nil {
+		return nil, false
+	}
+	values := make(map[string]string, len(names))
+	for i, name := range names {
+		values[name] = submatches[i+1]
+	}
+	return values, true
+}
+
+// FindOperation gets the operation for a request with the given method and path relative to the server. If multiple
+// paths match, the one with the fewest templated segments is used, e.g. /pets/mine before /pets/{id}.
+// It returns the URL, Operation, the values of the path parameters and if an operation was found.
+func (document Document) FindOperation(method string, path string) (string, *Operation, map[string]string, bool) {
+	urls := make([]string, 0, len(document.Paths))
+	for url := range document.Paths {
+		urls = append(urls, url)
+	}
+	sort.Slice(urls, func(i, j int) bool {
+		ti, tj := len(pathTemplatePattern.FindAllString(urls[i], -1)), len(pathTemplatePattern.FindAllString(urls[j], -1))
+		if ti != tj {
+			return ti < tj
+		}
+		return urls[i] < urls[j]
+	})
+
+	for _, url := range urls {
+		values, matches := MatchPath(url, path)
+		if !matches {
+			continue
+		}
+		if operation, found := document.Paths[url].Operations[strings.ToLower(method)]; found {
+			return url, &operation, values, true
+		}
+	}
+	return "", nil, nil, false
+}
+
+// TrimBaseUrl returns the path of a request URL relative to the base URL of a server. The base URL can be absolute or
+// only a path. ok is false if the request URL is not below the base URL.
+func TrimBaseUrl(requestUrl string, baseUrl string) (string, bool) {
+	request, err := neturl.Parse(requestUrl)
+	if err != nil {
+		return "", false
+	}
+	base, err := neturl.Parse(baseUrl)
+	if err != nil {
+		return "", false
+	}
+	if base.Host != "" && !strings.EqualFold(base.Host, request.Host) {
+		return "", false
+	}
+
+	requestPath := request.EscapedPath()
+	basePath := strings.TrimSuffix(base.EscapedPath(), "/")
+	if requestPath != basePath && !strings.HasPrefix(requestPath, basePath+"/") {
+		return "", false
+	}
+	path := strings.TrimPrefix(requestPath, basePath)
+	if path == "" {
+		path = "/"
+	}
+	return path, true
+}
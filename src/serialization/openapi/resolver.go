@@ -9,25 +9,81 @@ import (
 	"strings"
 )
 
+// refResolver resolves references in a Document. Every referenced Schema is loaded only once and all references to it
+// share the same pointer, which allows recursive and cyclic schemas.
+type refResolver struct {
+	// schemas maps absolute references (file#fragment) to the Schema they resolve to
+	schemas map[string]*Schema
+	// visited contains all schemas whose children have already been resolved
+	visited map[*Schema]bool
+}
+
+func newRefResolver() *refResolver {
+	return &refResolver{
+		schemas: make(map[string]*Schema),
+		visited: make(map[*Schema]bool),
+	}
+}
+
 func (document Document) ResolveRefs() error {
-	for _, schema := range document.Components.Schemas {
-		err := schema.resolveRef(document.AbsolutePath)
+	resolver := newRefResolver()
+
+	// Local references to the components of this document resolve to the components themselves
+	for name, schema := range document.Components.Schemas {
+		if schema != nil && schema.Ref == "" {
+			resolver.schemas[document.AbsolutePath+"#/components/schemas/"+name] = schema
+		}
+	}
+
+	for name, schema := range document.Components.Schemas {
+		resolved, err := resolver.schema(schema, document.AbsolutePath)
 		if err != nil {
 			return err
 		}
+		document.Components.Schemas[name] = resolved
+	}
+
+	for _, parameter := range document.Components.Parameters {
+		if err := parameter.resolveRef(document.AbsolutePath, resolver); err != nil {
+			return err
+		}
+	}
+
+	for _, response := range document.Components.Responses {
+		if err := response.resolveRef(document.AbsolutePath, resolver); err != nil {
+			return err
+		}
+	}
+
+	for _, scheme := range document.Components.SecuritySchemes {
+		if err := scheme.resolveRef(document.AbsolutePath); err != nil {
+			return err
+		}
 	}
 
 	for _, path := range document.Paths {
 		for _, parameter := range path.Parameters {
-			err := parameter.resolveRef(document.AbsolutePath)
+			err := parameter.resolveRef(document.AbsolutePath, resolver)
 			if err != nil {
 				return err
 			}
 		}
 
 		for _, op := range path.Operations {
+			for _, parameter := range op.Parameters {
+				if err := parameter.resolveRef(document.AbsolutePath, resolver); err != nil {
+					return err
+				}
+			}
+
+			if op.RequestBody != nil {
+				if err := op.RequestBody.resolveRef(document.AbsolutePath, resolver); err != nil {
+					return err
+				}
+			}
+
 			for _, response := range op.Responses {
-				err := response.resolveRef(document.AbsolutePath)
+				err := response.resolveRef(document.AbsolutePath, resolver)
 				if err != nil {
 					return err
 				}
@@ -51,39 +107,73 @@ func resolveRelativePath(anchor string, path string) (string, error) {
 	return filepath.Clean(filepath.Join(anchor, path)), nil
 }
 
-// resolveRef resolves the reference in a Schema and the references in the Schema's Properties and Items.
-func (s *Schema) resolveRef(currentPath string) error {
-	if s.Ref != "" {
-		schema := &Schema{}
-		var err error
-		var fragment string
+// schema returns the resolved Schema for s. If s is a reference, the referenced Schema is returned, otherwise s itself
+// is returned. The references in the Properties, Items, AdditionalProperties and the subschemas of AnyOf, OneOf and
+// AllOf are resolved as well.
+func (r *refResolver) schema(s *Schema, currentPath string) (*Schema, error) {
+	if s == nil {
+		return nil, nil
+	}
 
-		currentPath, fragment, err = getAbsoluteFileFragment(currentPath, s.Ref)
-		if err = resolveReference(currentPath, fragment, schema); err != nil {
-			return err
-		}
-		if err != nil {
+	if s.Ref == "" {
+		return s, r.schemaChildren(s, currentPath)
+	}
+
+	file, fragment, err := getAbsoluteFileFragment(currentPath, s.Ref)
+	if err != nil {
+		return nil, err
+	}
+
+	key := file + "#" + fragment
+	if cached, found := r.schemas[key]; found {
+		return cached, nil
+	}
+
+	schema := &Schema{}
+	if err = resolveReference(file, fragment, schema); err != nil {
+		return nil, err
+	}
+
+	// Cache the schema before resolving its children, such that references back to it end here
+	r.schemas[key] = schema
+	resolved, err := r.schema(schema, file)
+	if err != nil {
+		return nil, err
+	}
+	r.schemas[key] = resolved
+
+	return resolved, nil
+}
+
+// schemaChildren resolves the references of all schemas contained in s.
+func (r *refResolver) schemaChildren(s *Schema, currentPath string) error {
+	if r.visited[s] {
+		return nil
+	}
+	r.visited[s] = true
+
+	var err error
+	for name, property := range s.Properties {
+		if s.Properties[name], err = r.schema(property, currentPath); err != nil {
 			return err
 		}
+	}
 
-		if schema != nil {
-			*s = *schema
-		}
+	if s.Items, err = r.schema(s.Items, currentPath); err != nil {
+		return err
 	}
 
-	// Recursively resolve refs in properties
-	for _, property := range s.Properties {
-		err := property.resolveRef(currentPath)
-		if err != nil {
+	if s.AdditionalProperties != nil {
+		if s.AdditionalProperties.Schema, err = r.schema(s.AdditionalProperties.Schema, currentPath); err != nil {
 			return err
 		}
 	}
 
-	// Recursively resolve refs in items
-	if s.Items != nil {
-		err := s.Items.resolveRef(currentPath)
-		if err != nil {
-			return err
+	for _, subschemas := range [][]*Schema{s.AnyOf, s.OneOf, s.AllOf} {
+		for i, subschema := range subschemas {
+			if subschemas[i], err = r.schema(subschema, currentPath); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -91,51 +181,102 @@ func (s *Schema) resolveRef(currentPath string) error {
 }
 
 // resolveRef resolves the reference in a Parameter and the reference in the Parameter's Schema.
-func (p *Parameter) resolveRef(currentPath string) error {
+func (p *Parameter) resolveRef(currentPath string, resolver *refResolver) error {
 	if p.Ref != "" {
 		parameter := &Parameter{}
 		var err error
 		var fragment string
 
 		currentPath, fragment, err = getAbsoluteFileFragment(currentPath, p.Ref)
-		if err = resolveReference(currentPath, fragment, parameter); err != nil {
+		if err != nil {
 			return err
 		}
-		if err != nil {
+		if err = resolveReference(currentPath, fragment, parameter); err != nil {
 			return err
 		}
 
 		*p = *parameter
 	}
 
-	err := p.Schema.resolveRef(currentPath)
+	var err error
+	p.Schema, err = resolver.schema(p.Schema, currentPath)
 	return err
 }
 
 // resolveRef resolves the reference in a Response and the references in the Schema in the MediaType.
-func (r *Response) resolveRef(currentPath string) error {
+func (r *Response) resolveRef(currentPath string, resolver *refResolver) error {
 	if r.Ref != "" {
 		response := &Response{}
 		var err error
 		var fragment string
 
 		currentPath, fragment, err = getAbsoluteFileFragment(currentPath, r.Ref)
+		if err != nil {
+			return err
+		}
 		if err = resolveReference(currentPath, fragment, response); err != nil {
 			return err
 		}
+
+		*r = *response
+	}
+
+	for contentType, mediaType := range r.Content {
+		var err error
+		if mediaType.Schema, err = resolver.schema(mediaType.Schema, currentPath); err != nil {
+			return err
+		}
+		r.Content[contentType] = mediaType
+	}
+
+	return nil
+}
+
+// resolveRef resolves the reference in a RequestBody and the references in the Schema in the MediaType.
+func (b *RequestBody) resolveRef(currentPath string, resolver *refResolver) error {
+	if b.Ref != "" {
+		requestBody := &RequestBody{}
+		var err error
+		var fragment string
+
+		currentPath, fragment, err = getAbsoluteFileFragment(currentPath, b.Ref)
 		if err != nil {
 			return err
 		}
+		if err = resolveReference(currentPath, fragment, requestBody); err != nil {
+			return err
+		}
 
-		*r = *response
+		*b = *requestBody
 	}
 
-	for _, mediaType := range r.Content {
-		if err := mediaType.Schema.resolveRef(currentPath); err != nil {
+	for contentType, mediaType := range b.Content {
+		var err error
+		if mediaType.Schema, err = resolver.schema(mediaType.Schema, currentPath); err != nil {
 			return err
 		}
+		b.Content[contentType] = mediaType
+	}
+
+	return nil
+}
+
+// resolveRef resolves the reference in a SecurityScheme.
+func (s *SecurityScheme) resolveRef(currentPath string) error {
+	if s.Ref == "" {
+		return nil
 	}
 
+	file, fragment, err := getAbsoluteFileFragment(currentPath, s.Ref)
+	if err != nil {
+		return err
+	}
+
+	scheme := &SecurityScheme{}
+	if err = resolveReference(file, fragment, scheme); err != nil {
+		return err
+	}
+	*s = *scheme
 	return nil
 }
 
@@ -0,0 +1,46 @@
+package openapi
+
+type SecuritySchemeType string
+
+const (
+	SecuritySchemeTypeApiKey        SecuritySchemeType = "apiKey"
+	SecuritySchemeTypeHttp          SecuritySchemeType = "http"
+	SecuritySchemeTypeOAuth2        SecuritySchemeType = "oauth2"
+	SecuritySchemeTypeOpenIdConnect SecuritySchemeType = "openIdConnect"
+)
+
+type SecurityScheme struct {
+	Type         SecuritySchemeType `yaml:"type"`
+	Description  string             `yaml:"description"`
+	Name         string             `yaml:"name"`
+	In           ParameterIn        `yaml:"in"`
+	Scheme       string             `yaml:"scheme"`
+	BearerFormat string             `yaml:"bearerFormat"`
+	Flows        OAuthFlows         `yaml:"flows"`
+
+	Ref string `yaml:"$ref"`
+}
+
+type OAuthFlows struct {
+	ClientCredentials *OAuthFlow `yaml:"clientCredentials"`
+	Password          *OAuthFlow `yaml:"password"`
+}
+
+type OAuthFlow struct {
+	TokenUrl   string            `yaml:"tokenUrl"`
+	RefreshUrl string            `yaml:"refreshUrl"`
+	Scopes     map[string]string `yaml:"scopes"`
+}
+
+// SecurityRequirement maps the names of security schemes to the scopes required. All schemes of a requirement have to
+// be satisfied.
+type SecurityRequirement map[string][]string
+
+// FindSecurity returns the security requirements of an operation. Only one of the requirements has to be satisfied. The
+// requirements of the operation replace the requirements of the document.
+func (document Document) FindSecurity(operation Operation) []SecurityRequirement {
+	if operation.Security != nil {
+		return operation.Security
+	}
+	return document.Security
+}
@@ -0,0 +1,62 @@
+package serialization
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ResponseTime describes the expected response times in ms. Max limits every single response, Percentiles limit the
+// percentiles of the response times over all runs of a contract, e.g. 95 for p95. In YAML, either a number for Max or
+// a map with max and percentiles like p50, p95 or p99.9 can be given.
+type ResponseTime struct {
+	Max         int64
+	Percentiles map[float64]int64
+}
+
+func (r *ResponseTime) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var max int64
+	if err := unmarshal(&max); err == nil {
+		*r = ResponseTime{Max: max}
+		return nil
+	}
+
+	var limits map[string]int64
+	if err := unmarshal(&limits); err != nil {
+		return err
+	}
+
+	*r = ResponseTime{}
+	for key, limit := range limits {
+		if key == "max" {
+			r.Max = limit
+			continue
+		}
+
+		percentile, err := strconv.ParseFloat(strings.TrimPrefix(key, "p"), 64)
+		if !strings.HasPrefix(key, "p") || err != nil || percentile <= 0 || percentile > 100 {
+			return fmt.Errorf("invalid response time percentile %s, expected max or e.g. p95", key)
+		}
+		if r.Percentiles == nil {
+			r.Percentiles = make(map[float64]int64)
+		}
+		r.Percentiles[percentile] = limit
+	}
+	return nil
+}
+
+// SortedPercentiles returns the percentiles with a limit in ascending order.
+func (r ResponseTime) SortedPercentiles() []float64 {
+	percentiles := make([]float64, 0, len(r.Percentiles))
+	for percentile := range r.Percentiles {
+		percentiles = append(percentiles, percentile)
+	}
+	sort.Float64s(percentiles)
+	return percentiles
+}
+
+// PercentileName formats a percentile like in YAML, e.g. p95.
+func PercentileName(percentile float64) string {
+	return "p" + strconv.FormatFloat(percentile, 'f', -1, 64)
+}
@@ -0,0 +1,51 @@
+package serialization
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Retry describes when and how often a contract is run again, e.g. for eventually consistent endpoints. Without any
+// condition, a contract is retried as long as it fails.
+type Retry struct {
+	// Attempts is the maximum number of times the contract is run
+	Attempts int `yaml:"attempts"`
+	// Delay is the time in ms to wait before the second attempt
+	Delay int64 `yaml:"delay"`
+	// Backoff is the factor by which the delay grows after every attempt (default: 1)
+	Backoff float64 `yaml:"backoff"`
+	// MaxDelay limits the delay in ms between two attempts
+	MaxDelay int64 `yaml:"maxDelay"`
+	// Jitter randomizes every delay by up to the given fraction of it, e.g. 0.1 for ±10%
+	Jitter float64 `yaml:"jitter"`
+
+	// OnStatus retries the contract if the response has one of the status codes
+	OnStatus []int `yaml:"onStatus"`
+	// OnReason retries the contract if it failed with one of the failure reasons
+	OnReason []string `yaml:"onReason"`
+	// Until retries the contract until the values at the paths in the JSON body equal the given values
+	Until map[string]interface{} `yaml:"until"`
+}
+
+// HasCondition checks if any condition for retrying is set.
+func (r Retry) HasCondition() bool {
+	return len(r.OnStatus) > 0 || len(r.OnReason) > 0 || len(r.Until) > 0
+}
+
+// DelayBefore returns the time to wait before the given attempt, starting at 2 for the first retry.
+func (r Retry) DelayBefore(attempt int) time.Duration {
+	backoff := r.Backoff
+	if backoff <= 0 {
+		backoff = 1
+	}
+
+	delay := float64(r.Delay) * math.Pow(backoff, float64(attempt-2))
+	if r.MaxDelay > 0 && delay > float64(r.MaxDelay) {
+		delay = float64(r.MaxDelay)
+	}
+	if r.Jitter > 0 {
+		delay += delay * r.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(delay) * time.Millisecond
+}
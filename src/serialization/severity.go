@@ -0,0 +1,133 @@
+package serialization
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+type SeverityLevel string
+
+const (
+	SeverityIgnore SeverityLevel = "ignore" // The failure is not reported
+	SeverityInfo   SeverityLevel = "info"   // The failure is reported, but the contract passes
+	SeverityWarn   SeverityLevel = "warn"   // The failure causes a warning
+	SeverityError  SeverityLevel = "error"  // The failure fails the contract (default)
+)
+
+// FailureReasons are the reasons of the failures reported for contracts, which severity rules can refer to.
+var FailureReasons = []string{
+	"contract",
+	"http",
+	"io",
+	"format",
+	"unexpected.status",
+	"unexpected.schema",
+	"unexpected.content-type",
+	"unexpected.responseTime",
+	"unexpected.body",
+	"auth",
+	"unexpected.snapshot",
+}
+
+// SeverityRule sets the level of the failures it matches. A rule without a reason matches failures of every reason.
+type SeverityRule struct {
+	Reason string        `yaml:"reason"`
+	Level  SeverityLevel `yaml:"level"`
+	// Match is a regular expression the comment of the failure has to contain a match of, e.g. root\.legacyField
+	Match string `yaml:"match"`
+	// Tag restricts the rule to contracts with the tag
+	Tag string `yaml:"tag"`
+
+	match *regexp.Regexp
+}
+
+// Severity is a list of rules, of which the last one matching a failure sets its level. In YAML, either a list of
+// rules or a map from failure reasons to levels can be given.
+type Severity []SeverityRule
+
+func (s *Severity) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var levels map[string]SeverityLevel
+	if err := unmarshal(&levels); err == nil {
+		reasons := make([]string, 0, len(levels))
+		for reason := range levels {
+			reasons = append(reasons, reason)
+		}
+		sort.Strings(reasons)
+
+		rules := make(Severity, 0, len(levels))
+		for _, reason := range reasons {
+			rules = append(rules, SeverityRule{Reason: reason, Level: levels[reason]})
+		}
+		*s = rules
+	} else {
+		var rules []SeverityRule
+		if err := unmarshal(&rules); err != nil {
+			return err
+		}
+		*s = rules
+	}
+
+	for i := range *s {
+		if err := (*s)[i].compile(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compile checks the reason and level of the rule and compiles its match.
+func (r *SeverityRule) compile() error {
+	switch r.Level {
+	case SeverityIgnore, SeverityInfo, SeverityWarn, SeverityError:
+	default:
+		return fmt.Errorf("invalid severity level %q, expected ignore, info, warn or error", r.Level)
+	}
+	if r.Reason != "" && !containsReason(r.Reason) {
+		return fmt.Errorf("unknown failure reason %q in severity", r.Reason)
+	}
+	if r.Match != "" {
+		match, err := regexp.Compile(r.Match)
+		if err != nil {
+			return fmt.Errorf("invalid severity match %q: %w", r.Match, err)
+		}
+		r.match = match
+	}
+	return nil
+}
+
+// Matches checks if the rule applies to a failure with the reason and comment of a contract with the tags.
+func (r SeverityRule) Matches(reason string, comment string, tags []string) bool {
+	if r.Reason != "" && r.Reason != reason {
+		return false
+	}
+	if r.Tag != "" && !containsTag(tags, r.Tag) {
+		return false
+	}
+	if r.Match == "" {
+		return true
+	}
+	if r.match != nil {
+		return r.match.MatchString(comment)
+	}
+	matched, err := regexp.MatchString(r.Match, comment)
+	return err == nil && matched
+}
+
+func containsReason(reason string) bool {
+	for _, known := range FailureReasons {
+		if known == reason {
+			return true
+		}
+	}
+	return false
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
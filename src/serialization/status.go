@@ -0,0 +1,103 @@
+package serialization
+
+import (
+	"contract-testing/src/serialization/openapi"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MatchesStatus checks whether the status code is expected. If neither Status nor StatusPattern is set, 200 is
+// expected.
+func (e Expect) MatchesStatus(statusCode int) bool {
+	if e.StatusPattern == "" {
+		if e.Status == 0 {
+			return statusCode == 200
+		}
+		return statusCode == e.Status
+	}
+
+	for _, excluded := range e.ExcludedStatusPatterns {
+		if statusPatternMatches(excluded, statusCode) {
+			return false
+		}
+	}
+	return statusPatternMatches(e.StatusPattern, statusCode)
+}
+
+// ExpectedStatus describes the expected status code(s).
+func (e Expect) ExpectedStatus() string {
+	if e.StatusPattern != "" {
+		return e.StatusPattern
+	}
+	if e.Status == 0 {
+		return "200"
+	}
+	return strconv.Itoa(e.Status)
+}
+
+// statusPatternMatches checks if a status code matches a response key of an OpenAPI operation. The key is either a
+// status code, a range of status codes (e.g. 4XX) or default.
+func statusPatternMatches(pattern string, statusCode int) bool {
+	switch statusPatternPrecedence(pattern) {
+	case statusPrecedenceExact:
+		return pattern == strconv.Itoa(statusCode)
+	case statusPrecedenceRange:
+		return int(pattern[0]-'0') == statusCode/100
+	case statusPrecedenceDefault:
+		return true
+	}
+	return false
+}
+
+// The precedence of response keys in an OpenAPI operation. A status code takes precedence over a range, which takes
+// precedence over default.
+const (
+	statusPrecedenceExact = iota
+	statusPrecedenceRange
+	statusPrecedenceDefault
+	statusPrecedenceInvalid
+)
+
+func statusPatternPrecedence(pattern string) int {
+	if pattern == "default" {
+		return statusPrecedenceDefault
+	}
+	if len(pattern) != 3 || pattern[0] < '1' || pattern[0] > '5' {
+		return statusPrecedenceInvalid
+	}
+	if strings.ToUpper(pattern[1:]) == "XX" {
+		return statusPrecedenceRange
+	}
+	if _, err := strconv.Atoi(pattern); err == nil {
+		return statusPrecedenceExact
+	}
+	return statusPrecedenceInvalid
+}
+
+// sortedStatusPatterns returns the response keys of an operation ordered by their precedence.
+func sortedStatusPatterns(responses map[string]*openapi.Response) []string {
+	patterns := make([]string, 0, len(responses))
+	for pattern := range responses {
+		patterns = append(patterns, pattern)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		pi, pj := statusPatternPrecedence(patterns[i]), statusPatternPrecedence(patterns[j])
+		if pi != pj {
+			return pi < pj
+		}
+		return patterns[i] < patterns[j]
+	})
+	return patterns
+}
+
+// FindResponseKey returns the key of the response of an operation which documents the status code, taking the
+// precedence of the keys into account.
+func FindResponseKey(responses map[string]*openapi.Response, statusCode int) (string, bool) {
+	for _, pattern := range sortedStatusPatterns(responses) {
+		if statusPatternMatches(pattern, statusCode) {
+			return pattern, true
+		}
+	}
+	return "", false
+}
@@ -7,6 +7,7 @@ import (
 	"gopkg.in/yaml.v2"
 	"io/ioutil"
 	"log"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -16,18 +17,56 @@ type Expect struct {
 	SchemaName     string `yaml:"schema"`
 	ContentType    string `yaml:"contentType"`
 	SchemaResolved *openapi.Schema
-	ResponseTime   int64 `yaml:"responseTime"`
+	ResponseTime   ResponseTime `yaml:"responseTime"`
+	Xsd            string       `yaml:"xsd"`
+
+	// Snapshot is the path of a file the response body is compared with. SnapshotIgnore lists the JSON paths of values
+	// which are not compared, e.g. ids and timestamps.
+	Snapshot       string   `yaml:"snapshot"`
+	SnapshotIgnore []string `yaml:"snapshotIgnore"`
+
+	// StatusPattern is a response key from an OpenAPI operation (e.g. 2XX or default). If set, it is used instead of
+	// Status.
+	StatusPattern string
+	// ExcludedStatusPatterns are the response keys of an operation which take precedence over StatusPattern.
+	ExcludedStatusPatterns []string
 }
 
+type BodyType string
+
+const (
+	BodyTypeJson      BodyType = "json"      // The body is encoded as JSON (default)
+	BodyTypeForm      BodyType = "form"      // The body is a map encoded as application/x-www-form-urlencoded
+	BodyTypeMultipart BodyType = "multipart" // The body is a map encoded as multipart/form-data, which may upload files
+	BodyTypeRaw       BodyType = "raw"       // The body is a string, or a map with a file whose content is sent as is
+	BodyTypeFile      BodyType = "file"      // The body is the path of a file whose content is sent as is
+)
+
 type Contract struct {
-	Url        string                 `yaml:"url"`
-	Method     string                 `yaml:"method"`
-	Headers    map[string]string      `yaml:"headers"`
-	Expect     Expect                 `yaml:"expect"`
-	Name       string                 `yaml:"name"`
-	Parameters map[string]string      `yaml:"parameters"`
-	Body       map[string]interface{} `yaml:"body"`
-	Debug      bool                   `yaml:"debug"`
+	Url        string            `yaml:"url"`
+	Method     string            `yaml:"method"`
+	Headers    map[string]string `yaml:"headers"`
+	Expect     Expect            `yaml:"expect"`
+	Name       string            `yaml:"name"`
+	Parameters map[string]string `yaml:"parameters"`
+	Body       interface{}       `yaml:"body"`
+	BodyType   BodyType          `yaml:"bodyType"`
+	Debug      bool              `yaml:"debug"`
+	Http       HttpConfig        `yaml:"http"`
+	Retry      *Retry            `yaml:"retry"`
+	Auth       Auths             `yaml:"auth"`
+	// Tags are used to filter the results in the report, contracts of spec files have the tags of their operation
+	Tags []string `yaml:"tags"`
+	// Severity rules of the contract take precedence over the rules of the suite
+	Severity Severity `yaml:"severity"`
+
+	// ParameterSchemas maps parameter keys (location:name) to the schema their values have to match
+	ParameterSchemas map[string]*openapi.Schema
+	// Recorded is validated instead of sending a request, if it is set
+	Recorded *RecordedResponse `yaml:"-"`
+	// StripCredentials are the names of headers and query parameters which are removed from the request before the
+	// credentials of Auth are added, e.g. credentials set in the headers of the suite for security contracts
+	StripCredentials []string `yaml:"-"`
 
 	AnyOf []*Contract `yaml:"anyOf"`
 }
@@ -36,20 +75,48 @@ type SpecFile struct {
 	Path       string               `yaml:"path"`
 	BaseUrl    string               `yaml:"baseUrl"`
 	Operations map[string]Operation `yaml:"operations"`
+
+	// Server selects one of the servers from the OpenAPI document by its index or description
+	Server          string            `yaml:"server"`
+	ServerVariables map[string]string `yaml:"serverVariables"`
+
+	// Auth maps the names of security schemes from the OpenAPI document to credentials
+	Auth map[string]Auth `yaml:"auth"`
+	// NegativeSecurity creates additional contracts for secured operations, which send no or invalid credentials and
+	// expect the request to be rejected
+	NegativeSecurity bool `yaml:"negativeSecurity"`
 }
 
 type Operation struct {
-	Parameters    map[string]string      `yaml:"parameters"`
-	ParameterSets []map[string]string    `yaml:"parameterSets"`
-	Body          map[string]interface{} `yaml:"body"`
+	Parameters    map[string]string   `yaml:"parameters"`
+	ParameterSets []map[string]string `yaml:"parameterSets"`
+	Body          interface{}         `yaml:"body"`
+	BodyType      BodyType            `yaml:"bodyType"`
+	Severity      Severity            `yaml:"severity"`
 }
 
 type Suite struct {
 	SpecFiles []SpecFile        `yaml:"specFiles"`
+	HarFiles  []HarFile         `yaml:"harFiles"`
 	Contracts []Contract        `yaml:"contracts"`
 	Headers   map[string]string `yaml:"headers"`
 	Schemas   map[string]openapi.Schema
-	Severity  map[string]string `yaml:"severity"`
+	Severity  Severity   `yaml:"severity"`
+	Http      HttpConfig `yaml:"http"`
+	Auth      Auths      `yaml:"auth"`
+
+	// KnownFailures are reported as XFAIL instead of failing the contracts until they expire
+	KnownFailures KnownFailures `yaml:"knownFailures"`
+
+	// MaxConcurrentPerHost is the maximum number of requests sent to the same host at a time, 0 for no limit
+	MaxConcurrentPerHost int `yaml:"maxConcurrentPerHost"`
+
+	// Redact are the names of headers, query parameters and body fields whose values are redacted in reports, in
+	// addition to those which look like credentials
+	Redact []string `yaml:"redact"`
+
+	// UpdateSnapshots rewrites the snapshot files with the response bodies instead of comparing them
+	UpdateSnapshots bool `yaml:"-"`
 }
 
 type wrapper struct {
@@ -83,7 +150,7 @@ func NewContractFromOperation(url string, method string, operation openapi.Opera
 	}
 
 	subcontracts := make([]*Contract, 0)
-	for statusCode := range operation.Responses {
+	for _, statusCode := range sortedStatusPatterns(operation.Responses) {
 		subcontract, err := NewContractFromOperationWithStatus(url, method, operation, statusCode)
 		if err != nil {
 			return nil, err
@@ -99,106 +166,243 @@ func NewContractFromOperation(url string, method string, operation openapi.Opera
 	}, nil
 }
 
+// NewContractFromOperationWithStatus creates a contract for the response of the operation with the given key, which is
+// a status code, a range of status codes (e.g. 2XX) or default. Response keys with higher precedence are excluded from
+// the expected status codes. If the response has multiple media types, a subcontract is created for each of them.
 func NewContractFromOperationWithStatus(url string, method string, operation openapi.Operation, statusCode string) (*Contract, error) {
-	var statusCodeInt int64
-	var err error
-	if statusCodeInt, err = strconv.ParseInt(statusCode, 10, 64); err != nil {
+	precedence := statusPatternPrecedence(statusCode)
+	if precedence == statusPrecedenceInvalid {
 		return nil, fmt.Errorf("invalid status code: %s", statusCode)
 	}
 
 	if _, found := operation.Responses[statusCode]; !found {
 		return nil, fmt.Errorf("could not find %s response in operation %s", statusCode, operation.OperationId)
 	}
-	if _, found := operation.Responses[statusCode].Content["application/json"]; !found {
-		return nil, fmt.Errorf("could not find application/json content in operation %s (%s)", operation.OperationId, statusCode)
-	}
 
-	schema := operation.Responses[statusCode].Content["application/json"].Schema
+	expect := Expect{}
+	if precedence == statusPrecedenceExact {
+		expect.Status, _ = strconv.Atoi(statusCode)
+	} else {
+		expect.StatusPattern = statusCode
+		for pattern := range operation.Responses {
+			if statusPatternPrecedence(pattern) < precedence {
+				expect.ExcludedStatusPatterns = append(expect.ExcludedStatusPatterns, pattern)
+			}
+		}
+		sort.Strings(expect.ExcludedStatusPatterns)
+	}
 
-	return &Contract{
-		Url:    url,
-		Method: method,
-		Expect: Expect{
-			Status:         int(statusCodeInt),
-			SchemaResolved: schema,
-			ContentType:    "application/json",
-		},
+	contract := &Contract{
+		Url:        url,
+		Method:     method,
+		Expect:     expect,
 		Name:       fmt.Sprintf("%s[response:%s]", operation.OperationId, statusCode),
 		Parameters: make(map[string]string, 0),
-	}, nil
+	}
+
+	content := operation.Responses[statusCode].Content
+	mediaTypes := make([]string, 0, len(content))
+	for mediaType := range content {
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	sort.Strings(mediaTypes)
+
+	// A response without content has no body, so there is nothing more to expect
+	if len(mediaTypes) == 0 {
+		return contract, nil
+	}
+
+	if len(mediaTypes) == 1 {
+		contract.expectMediaType(mediaTypes[0], content[mediaTypes[0]])
+		return contract, nil
+	}
+
+	// For multiple media types, the media type is negotiated using the Accept header
+	contract.AnyOf = make([]*Contract, 0, len(mediaTypes))
+	for _, mediaType := range mediaTypes {
+		subcontract := &Contract{
+			Url:        url,
+			Method:     method,
+			Headers:    map[string]string{"Accept": mediaType},
+			Expect:     expect,
+			Name:       fmt.Sprintf("%s[mediaType:%s]", contract.Name, mediaType),
+			Parameters: make(map[string]string, 0),
+		}
+		subcontract.expectMediaType(mediaType, content[mediaType])
+		contract.AnyOf = append(contract.AnyOf, subcontract)
+	}
+	return contract, nil
 }
 
+// expectMediaType sets the expected content type and, if the media type can be validated, the expected schema.
+func (c *Contract) expectMediaType(mediaType string, content openapi.MediaType) {
+	c.Expect.ContentType = mediaType
+	if openapi.IsJsonMediaType(mediaType) || openapi.IsXmlMediaType(mediaType) {
+		c.Expect.SchemaResolved = content.Schema
+	}
+}
+
+// sortedOperationIds returns the ids of the operations of the spec file in order, so contracts are created in the same
+// order on every run.
+func (s SpecFile) sortedOperationIds() []string {
+	operationIds := make([]string, 0, len(s.Operations))
+	for operationId := range s.Operations {
+		operationIds = append(operationIds, operationId)
+	}
+	sort.Strings(operationIds)
+	return operationIds
+}
+
+// CreateContracts creates the contracts for the operations of the spec file, ordered by their id.
 func (s SpecFile) CreateContracts() ([]Contract, error) {
 	doc, err := openapi.LoadDocument(s.Path)
 	if err != nil {
 		return nil, err
 	}
 
+	allContracts := make([]Contract, 0, len(s.Operations))
+	for _, operationId := range s.sortedOperationIds() {
+		sop := s.Operations[operationId]
+		url, _, op, found := doc.FindOperationById(operationId)
+		if !found {
+			return nil, fmt.Errorf("operation %s not found", operationId)
+		}
+
+		baseUrls, err := s.findBaseUrls(doc.FindServers(url, *op))
+		if err != nil {
+			return nil, fmt.Errorf("operation %s: %s", operationId, err)
+		}
+
+		for _, baseUrl := range baseUrls {
+			contracts, err := s.createContractsWithBaseUrl(doc, baseUrl, operationId, sop)
+			if err != nil {
+				return nil, err
+			}
+			allContracts = append(allContracts, contracts...)
+		}
+	}
+
+	return allContracts, nil
+}
+
+// findBaseUrls returns the base URLs to create contracts for. If the spec file has a baseUrl, only that one is used.
+// Otherwise, the URL of the selected server, or of all servers if none is selected, is used with its variables
+// substituted.
+func (s SpecFile) findBaseUrls(servers []openapi.Server) ([]string, error) {
 	if s.BaseUrl != "" {
-		return s.createContractsWithBaseUrl(doc, s.BaseUrl)
+		return []string{s.BaseUrl}, nil
 	}
 
-	if len(doc.Servers) == 0 {
+	if len(servers) == 0 {
 		return nil, fmt.Errorf("specify either a baseUrl in the contest suite or servers in the OpenAPI document")
 	}
 
-	allContracts := make([]Contract, 0, len(s.Operations)*len(doc.Servers))
-	for _, server := range doc.Servers {
-		contracts, err := s.createContractsWithBaseUrl(doc, server.Url)
+	if s.Server != "" {
+		server, err := s.selectServer(servers)
 		if err != nil {
 			return nil, err
 		}
-		allContracts = append(allContracts, contracts...)
+		servers = []openapi.Server{server}
 	}
 
-	return allContracts, nil
+	baseUrls := make([]string, 0, len(servers))
+	for _, server := range servers {
+		baseUrl, err := server.ResolveUrl(s.ServerVariables)
+		if err != nil {
+			return nil, err
+		}
+		baseUrls = append(baseUrls, baseUrl)
+	}
+	return baseUrls, nil
 }
 
-func (s SpecFile) createContractsWithBaseUrl(doc *openapi.Document, baseUrl string) ([]Contract, error) {
-	contracts := make([]Contract, 0, len(s.Operations))
-
-	for operationId, sop := range s.Operations {
-		url, method, op, found := doc.FindOperationById(operationId)
-		if !found {
-			return nil, fmt.Errorf("operation %s not found", operationId)
+// selectServer finds the server selected by SpecFile.Server, either by its index or by its description.
+func (s SpecFile) selectServer(servers []openapi.Server) (openapi.Server, error) {
+	if index, err := strconv.Atoi(s.Server); err == nil {
+		if index < 0 || index >= len(servers) {
+			return openapi.Server{}, fmt.Errorf("server index %d out of range, %d servers available", index, len(servers))
 		}
+		return servers[index], nil
+	}
 
-		contract, err := NewContractFromOperation(baseUrl+url, method, *op)
-		if err != nil {
-			return nil, err
+	for _, server := range servers {
+		if server.Description == s.Server {
+			return server, nil
 		}
+	}
+	return openapi.Server{}, fmt.Errorf("could not find server with description %s", s.Server)
+}
 
-		// Copy parameters from the spec file operation to the contract
-		contract.Parameters = deepCopyStringMap(sop.Parameters)
+func (s SpecFile) createContractsWithBaseUrl(doc *openapi.Document, baseUrl string, operationId string, sop Operation) ([]Contract, error) {
+	contracts := make([]Contract, 0, len(sop.ParameterSets)+1)
 
-		if sop.ParameterSets == nil {
-			sop.ParameterSets = make([]map[string]string, 1)
-			sop.ParameterSets[0] = sop.Parameters
-		}
+	url, method, op, found := doc.FindOperationById(operationId)
+	if !found {
+		return nil, fmt.Errorf("operation %s not found", operationId)
+	}
 
-		contract.Body = sop.Body
-		contract.copyAttributesToChildren()
+	contract, err := NewContractFromOperation(baseUrl+url, method, *op)
+	if err != nil {
+		return nil, err
+	}
 
-		for i, parameterSet := range sop.ParameterSets {
-			parameterSetContract := contract.deepCopy()
-			if len(sop.ParameterSets) > 1 {
-				parameterSetContract.UpdateName(fmt.Sprintf("%s[paramSet:%d]", contract.Name, i))
-			}
+	// Copy parameters from the spec file operation to the contract
+	contract.Parameters = deepCopyStringMap(sop.Parameters)
+	contract.Tags = op.Tags
+	contract.Severity = sop.Severity
+
+	if sop.ParameterSets == nil {
+		sop.ParameterSets = make([]map[string]string, 1)
+		sop.ParameterSets[0] = sop.Parameters
+	}
 
-			parameterSetContract.Parameters = deepCopyStringMap(parameterSet)
-			parameterSetContract.checkPathParameters(doc.Paths[url].Parameters, operationId)
-			parameterSetContract.copyAttributesToChildren()
+	contract.Body = sop.Body
+	contract.BodyType = sop.BodyType
+	if contract.BodyType == "" && op.RequestBody != nil {
+		contract.inferBodyType(op.RequestBody.Content)
+	}
+	contract.applySecurity(doc, *op, s.Auth)
+	contract.copyAttributesToChildren()
+
+	for i, parameterSet := range sop.ParameterSets {
+		parameterSetContract := contract.deepCopy()
+		if len(sop.ParameterSets) > 1 {
+			parameterSetContract.UpdateName(fmt.Sprintf("%s[paramSet:%d]", contract.Name, i))
+		}
+
+		parameterSetContract.Parameters = deepCopyStringMap(parameterSet)
+		parameterSetContract.checkParameters(doc.Paths[url].MergedParameters(*op), operationId)
+		parameterSetContract.copyAttributesToChildren()
+
+		contracts = append(contracts, *parameterSetContract)
+	}
 
-			contracts = append(contracts, *parameterSetContract)
+	if s.NegativeSecurity {
+		for _, securityContract := range NewSecurityContracts(baseUrl+url, method, doc, *op) {
+			securityContract.Parameters = deepCopyStringMap(sop.ParameterSets[0])
+			securityContract.Body = contract.Body
+			securityContract.BodyType = contract.BodyType
+			securityContract.Tags = op.Tags
+			securityContract.Severity = sop.Severity
+			securityContract.checkParameters(doc.Paths[url].MergedParameters(*op), operationId)
+			securityContract.copyAttributesToChildren()
+
+			contracts = append(contracts, *securityContract)
 		}
 	}
 	return contracts, nil
 }
 
-// checkPathParameters checks if all parameters from the path are in the contracts parameters. If the location part of
-// the parameter is missing in the Contract, it is added using the information from the path.
-func (c *Contract) checkPathParameters(parameters []*openapi.Parameter, operationId string) {
+// checkParameters checks if all parameters from the path and operation are in the contracts parameters. If the location
+// part of the parameter is missing in the Contract, it is added using the information from the parameter. The schemas
+// of the parameters are added to the Contract, so the values can be validated.
+func (c *Contract) checkParameters(parameters []*openapi.Parameter, operationId string) {
+	c.ParameterSchemas = make(map[string]*openapi.Schema)
 	for _, parameter := range parameters {
+		if parameter.Schema != nil {
+			c.ParameterSchemas[string(parameter.In)+":"+parameter.Name] = parameter.Schema
+		}
+
 		// Check if the contract has the parameter including the location part
 		_, found := c.Parameters[string(parameter.In)+":"+parameter.Name]
 		if found {
@@ -219,14 +423,63 @@ func (c *Contract) checkPathParameters(parameters []*openapi.Parameter, operatio
 	}
 }
 
-// copyAttributesToChildren recursively copies Contract.Parameters and Contract.Body to its subcontracts (anyOf)
+// inferBodyType sets the BodyType from the media types of an OpenAPI request body. JSON is preferred over forms and
+// multipart forms. Any other media type is sent as raw body with the media type as Content-Type header.
+func (c *Contract) inferBodyType(content map[string]openapi.MediaType) {
+	mediaTypes := make([]string, 0, len(content))
+	for mediaType := range content {
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	sort.Strings(mediaTypes)
+
+	for _, mediaType := range mediaTypes {
+		if openapi.IsJsonMediaType(mediaType) {
+			c.BodyType = BodyTypeJson
+			return
+		}
+	}
+	for _, mediaType := range mediaTypes {
+		switch openapi.BaseMediaType(mediaType) {
+		case "application/x-www-form-urlencoded":
+			c.BodyType = BodyTypeForm
+			return
+		case "multipart/form-data":
+			c.BodyType = BodyTypeMultipart
+			return
+		}
+	}
+	if len(mediaTypes) > 0 {
+		c.BodyType = BodyTypeRaw
+		if c.Headers == nil {
+			c.Headers = make(map[string]string)
+		}
+		c.Headers["Content-Type"] = mediaTypes[0]
+	}
+}
+
+// copyAttributesToChildren recursively copies Contract.Parameters, Contract.Body, Contract.BodyType, Contract.Auth,
+// Contract.Recorded and Contract.StripCredentials to its subcontracts (anyOf). Headers of the contract are added to the subcontracts, unless they
+// override them.
 func (c *Contract) copyAttributesToChildren() {
 	if c.AnyOf == nil {
 		return
 	}
 	for _, contract := range c.AnyOf {
 		contract.Parameters = c.Parameters
+		contract.ParameterSchemas = c.ParameterSchemas
 		contract.Body = c.Body
+		contract.BodyType = c.BodyType
+		contract.Auth = c.Auth
+		contract.Recorded = c.Recorded
+		contract.StripCredentials = c.StripCredentials
+		for key, value := range c.Headers {
+			if _, found := contract.Headers[key]; !found {
+				if contract.Headers == nil {
+					contract.Headers = make(map[string]string)
+				}
+				contract.Headers[key] = value
+			}
+		}
 
 		contract.copyAttributesToChildren()
 	}
@@ -239,12 +492,8 @@ func (c *Contract) UpdateName(new string) {
 }
 
 func (c *Contract) updateName(old string, new string) {
-	if c.AnyOf == nil {
-		return
-	}
 	c.Name = strings.ReplaceAll(c.Name, old, new)
 	for _, contract := range c.AnyOf {
-
 		contract.updateName(old, new)
 	}
 }
@@ -257,11 +506,20 @@ func (c *Contract) deepCopy() *Contract {
 		Expect:     c.Expect,
 		Name:       c.Name,
 		Parameters: deepCopyStringMap(c.Parameters),
-		Body:       deepCopyMap(c.Body),
+		Body:       deepCopyInterface(c.Body),
+		BodyType:   c.BodyType,
 		Debug:      c.Debug,
+		Http:       c.Http,
+		Retry:      c.Retry,
+		Auth:       c.Auth,
+		Tags:       c.Tags,
+		Severity:   c.Severity,
 		AnyOf:      make([]*Contract, len(c.AnyOf)),
+
+		ParameterSchemas: c.ParameterSchemas,
+		Recorded:         c.Recorded,
+		StripCredentials: c.StripCredentials,
 	}
-	copied.Body = deepCopyMap(c.Body)
 	for k, v := range c.AnyOf {
 		copied.AnyOf[k] = v.deepCopy()
 	}
@@ -272,16 +530,14 @@ func (c *Contract) deepCopy() *Contract {
 func deepCopyInterface(m interface{}) interface{} {
 	switch m.(type) {
 	case map[string]string:
-		log.Println("copy string map")
 		return deepCopyStringMap(m.(map[string]string))
 	case map[string]interface{}:
-		log.Println("copy map")
 		return deepCopyMap(m.(map[string]interface{}))
+	case map[interface{}]interface{}:
+		return deepCopyInterfaceMap(m.(map[interface{}]interface{}))
 	case []interface{}:
-		log.Println("copy array")
 		return deepCopyArray(m.([]interface{}))
 	default:
-		log.Println("copy default")
 		return m
 	}
 }
@@ -302,6 +558,14 @@ func deepCopyMap(m map[string]interface{}) map[string]interface{} {
 	return copied
 }
 
+func deepCopyInterfaceMap(m map[interface{}]interface{}) map[interface{}]interface{} {
+	copied := make(map[interface{}]interface{})
+	for k, v := range m {
+		copied[k] = deepCopyInterface(v)
+	}
+	return copied
+}
+
 func deepCopyArray(m []interface{}) []interface{} {
 	copied := make([]interface{}, len(m))
 	for k, v := range m {
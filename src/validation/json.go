@@ -1,9 +1,11 @@
-package main
+package validation
 
 import (
 	"encoding/json"
 	"errors"
+	"regexp"
 	"strconv"
+	"strings"
 )
 
 func JsonUnmarshal(data []byte) (interface{}, error) {
@@ -59,12 +61,13 @@ func JsonUnmarshal(data []byte) (interface{}, error) {
 	return unmarshalled, nil
 }
 
-func retypeKeysToStrings(m interface{}) (interface{}, error) {
+// RetypeKeysToStrings converts the maps with interface{} keys returned by the YAML decoder to maps with string keys.
+func RetypeKeysToStrings(m interface{}) (interface{}, error) {
 	switch m.(type) {
 	case map[string]interface{}:
 		retypedMap := m.(map[string]interface{})
 		for k, v := range retypedMap {
-			retyped, err := retypeKeysToStrings(v)
+			retyped, err := RetypeKeysToStrings(v)
 			if err != nil {
 				return nil, err
 			}
@@ -77,7 +80,7 @@ func retypeKeysToStrings(m interface{}) (interface{}, error) {
 			return nil, err
 		}
 		for k, v := range val {
-			retyped, err := retypeKeysToStrings(v)
+			retyped, err := RetypeKeysToStrings(v)
 			if err != nil {
 				return nil, err
 			}
@@ -87,7 +90,7 @@ func retypeKeysToStrings(m interface{}) (interface{}, error) {
 	case []interface{}:
 		arr := m.([]interface{})
 		for i, v := range arr {
-			retyped, err := retypeKeysToStrings(v)
+			retyped, err := RetypeKeysToStrings(v)
 			if err != nil {
 				return nil, err
 			}
@@ -117,10 +120,41 @@ func retypeMapToStringKeys(m map[interface{}]interface{}) (map[string]interface{
 }
 
 func JsonMarshal(data interface{}) ([]byte, error) {
-	retyped, err := retypeKeysToStrings(data)
+	retyped, err := RetypeKeysToStrings(data)
 	if err != nil {
 		return nil, err
 	}
 
 	return json.Marshal(retyped)
 }
+
+// JsonPathIndexPattern matches the array indices of a path in the form accepted by JsonPathValue.
+var JsonPathIndexPattern = regexp.MustCompile(`\[(\d+)]`)
+
+// JsonPathValue returns the value at a path in a value returned by JsonUnmarshal. The path consists of property names
+// separated by dots and array indices in brackets, e.g. items[0].status. An empty path returns the value itself.
+func JsonPathValue(value interface{}, path string) (interface{}, bool) {
+	path = JsonPathIndexPattern.ReplaceAllString(path, ".$1")
+	for _, part := range strings.Split(path, ".") {
+		if part == "" {
+			continue
+		}
+
+		switch v := value.(type) {
+		case map[string]interface{}:
+			var found bool
+			if value, found = v[part]; !found {
+				return nil, false
+			}
+		case []interface{}:
+			index, err := strconv.Atoi(part)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, false
+			}
+			value = v[index]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
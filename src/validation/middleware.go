@@ -0,0 +1,455 @@
+package validation
+
+import (
+	"bufio"
+	"bytes"
+	"contract-testing/src/serialization"
+	"contract-testing/src/serialization/openapi"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+type ViolationReason string
+
+const (
+	ViolationOperation   ViolationReason = "unknown.operation"       // No operation of the document matches the request
+	ViolationParameter   ViolationReason = "invalid.parameter"       // A missing or invalid request parameter
+	ViolationRequestBody ViolationReason = "invalid.body"            // A missing or invalid request body
+	ViolationStatus      ViolationReason = "unexpected.status"       // An undocumented HTTP status code
+	ViolationContentType ViolationReason = "unexpected.content-type" // An undocumented content type
+	ViolationFormat      ViolationReason = "format"                  // A body which is not valid JSON
+	ViolationSchema      ViolationReason = "unexpected.schema"       // A response body not matching its schema
+)
+
+// Violation describes how a request or the response to it does not match the OpenAPI document.
+type Violation struct {
+	Reason ViolationReason
+	Method string
+	// Path is the path of the request, Operation the id of the matching operation, if one was found
+	Path      string
+	Operation string
+	// Response is true if the response violates the document, false if the request does
+	Response bool
+	Message  string
+}
+
+func (v Violation) String() string {
+	side := "request"
+	if v.Response {
+		side = "response"
+	}
+	return fmt.Sprintf("%s %s: %s %s: %s", v.Method, v.Path, side, v.Reason, v.Message)
+}
+
+// Options configure how the middleware reports violations.
+type Options struct {
+	// OnViolation is called for every violation
+	OnViolation func(r *http.Request, violation Violation)
+	// Logger logs every violation. If neither OnViolation nor Logger is set, violations are logged to the standard logger
+	Logger *log.Logger
+	// Strict answers with status 500 instead of calling the handler or sending its response, if there are violations
+	Strict bool
+	// BaseUrl is the URL the paths of the document are relative to. If it is empty, the servers of the document are used
+	BaseUrl string
+}
+
+// Middleware returns a function which wraps an http.Handler, such that every request and response is validated against
+// the OpenAPI document. Parameters are checked against their schemas and JSON bodies against the schema of their media
+// type. Responses are buffered in strict mode, otherwise they are validated after they were sent and their bodies are
+// only copied if they are validated against a JSON schema and not larger than maxRecordedBody. Handlers can flush
+// responses, which has no effect in strict mode, and hijack connections, whose responses are not validated.
+func Middleware(doc *openapi.Document, options Options) func(http.Handler) http.Handler {
+	basePaths := documentBasePaths(doc, options.BaseUrl)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			v := &requestValidator{doc: doc, request: r}
+			v.checkRequest(basePaths)
+			if options.Strict && len(v.violations) > 0 {
+				options.report(r, v.violations)
+				failStrict(w, v.violations)
+				return
+			}
+
+			recorder := &responseRecorder{
+				ResponseWriter: w,
+				buffer:         options.Strict,
+				recordBody:     v.validatesResponseBody,
+				statusCode:     http.StatusOK,
+			}
+			next.ServeHTTP(recorder, r)
+			if recorder.hijacked {
+				// The handler took over the connection, so there is no response to validate
+				options.report(r, v.violations)
+				return
+			}
+
+			requestViolations := len(v.violations)
+			if v.operation != nil {
+				v.checkResponse(recorder.statusCode, recorder.contentType(), recorder.body.Bytes(), recorder.size)
+			}
+			options.report(r, v.violations)
+
+			if options.Strict && len(v.violations) > requestViolations {
+				failStrict(w, v.violations[requestViolations:])
+			} else if options.Strict {
+				recorder.flush()
+			}
+		})
+	}
+}
+
+func (o Options) report(r *http.Request, violations []Violation) {
+	for _, violation := range violations {
+		if o.OnViolation != nil {
+			o.OnViolation(r, violation)
+		}
+		if o.Logger != nil {
+			o.Logger.Printf("contract violation: %s", violation)
+		} else if o.OnViolation == nil {
+			log.Printf("contract violation: %s", violation)
+		}
+	}
+}
+
+// failStrict replaces the response with status 500 listing the violations.
+func failStrict(w http.ResponseWriter, violations []Violation) {
+	for key := range w.Header() {
+		w.Header().Del(key)
+	}
+	messages := make([]string, 0, len(violations))
+	for _, violation := range violations {
+		messages = append(messages, violation.String())
+	}
+	http.Error(w, "contract violation\n"+strings.Join(messages, "\n"), http.StatusInternalServerError)
+}
+
+// documentBasePaths returns the paths below which the paths of the document are served. Server variables are replaced
+// by their default values.
+func documentBasePaths(doc *openapi.Document, baseUrl string) []string {
+	urls := make([]string, 0, len(doc.Servers))
+	if baseUrl != "" {
+		urls = append(urls, baseUrl)
+	}
+	for _, server := range doc.Servers {
+		if baseUrl != "" {
+			break
+		}
+		if resolved, err := server.ResolveUrl(nil); err == nil {
+			urls = append(urls, resolved)
+		}
+	}
+
+	paths := make([]string, 0, len(urls))
+	for _, u := range urls {
+		if parsed, err := url.Parse(u); err == nil {
+			paths = append(paths, parsed.EscapedPath())
+		}
+	}
+	if len(paths) == 0 {
+		paths = append(paths, "/")
+	}
+	return paths
+}
+
+type requestValidator struct {
+	doc        *openapi.Document
+	request    *http.Request
+	operation  *openapi.Operation
+	violations []Violation
+}
+
+func (v *requestValidator) violation(reason ViolationReason, response bool, message string) {
+	violation := Violation{
+		Reason:   reason,
+		Method:   v.request.Method,
+		Path:     v.request.URL.Path,
+		Response: response,
+		Message:  message,
+	}
+	if v.operation != nil {
+		violation.Operation = v.operation.OperationId
+	}
+	v.violations = append(v.violations, violation)
+}
+
+func (v *requestValidator) checkRequest(basePaths []string) {
+	for _, basePath := range basePaths {
+		path, ok := openapi.TrimBaseUrl(v.request.URL.EscapedPath(), basePath)
+		if !ok {
+			continue
+		}
+		if pathUrl, operation, values, found := v.doc.FindOperation(v.request.Method, path); found {
+			v.operation = operation
+			v.checkParameters(v.doc.Paths[pathUrl].MergedParameters(*operation), values)
+			v.checkRequestBody()
+			return
+		}
+	}
+	v.violation(ViolationOperation, false, "no operation matches the request")
+}
+
+func (v *requestValidator) checkParameters(parameters []*openapi.Parameter, pathValues map[string]string) {
+	for _, parameter := range parameters {
+		value, found := v.parameterValue(parameter, pathValues)
+		name := fmt.Sprintf("%s parameter %s", parameter.In, parameter.Name)
+		if !found {
+			if parameter.Required {
+				v.violation(ViolationParameter, false, "missing "+name)
+			}
+			continue
+		}
+		if parameter.Schema == nil {
+			continue
+		}
+
+		messages := make([]string, 0)
+		if !CheckSchema(*parameter.Schema, ParseParameterValue(value, *parameter.Schema), name, &messages) {
+			v.violation(ViolationParameter, false, strings.Join(messages, ", "))
+		}
+	}
+}
+
+func (v *requestValidator) parameterValue(parameter *openapi.Parameter, pathValues map[string]string) (string, bool) {
+	switch parameter.In {
+	case openapi.ParameterInPath:
+		value, found := pathValues[parameter.Name]
+		if unescaped, err := url.PathUnescape(value); err == nil {
+			value = unescaped
+		}
+		return value, found
+	case openapi.ParameterInQuery:
+		values, found := v.request.URL.Query()[parameter.Name]
+		if !found {
+			return "", false
+		}
+		return strings.Join(values, ","), true
+	case openapi.ParameterInHeader:
+		values := v.request.Header.Values(parameter.Name)
+		return strings.Join(values, ","), len(values) > 0
+	case openapi.ParameterInCookie:
+		cookie, err := v.request.Cookie(parameter.Name)
+		if err != nil {
+			return "", false
+		}
+		return cookie.Value, true
+	}
+	return "", false
+}
+
+// checkRequestBody validates the request body. The body is read and replaced, such that the handler can still read it.
+func (v *requestValidator) checkRequestBody() {
+	requestBody := v.operation.RequestBody
+	if requestBody == nil || v.request.Body == nil {
+		return
+	}
+
+	data, err := ioutil.ReadAll(v.request.Body)
+	v.request.Body.Close()
+	v.request.Body = ioutil.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		v.violation(ViolationRequestBody, false, err.Error())
+		return
+	}
+
+	if len(data) == 0 {
+		if requestBody.Required {
+			v.violation(ViolationRequestBody, false, "missing request body")
+		}
+		return
+	}
+	v.checkBody(requestBody.Content, v.request.Header.Get("Content-Type"), data, false)
+}
+
+// checkResponse validates the response of the handler. size is the length of the body, which is only validated if it was
+// recorded completely.
+func (v *requestValidator) checkResponse(statusCode int, contentType string, body []byte, size int) {
+	key, found := serialization.FindResponseKey(v.operation.Responses, statusCode)
+	if !found {
+		v.violation(ViolationStatus, true, fmt.Sprintf("status %d is not documented", statusCode))
+		return
+	}
+
+	content := v.operation.Responses[key].Content
+	if size == 0 || len(content) == 0 {
+		return
+	}
+	if len(body) < size {
+		v.matchMediaType(content, contentType, true)
+		return
+	}
+	v.checkBody(content, contentType, body, true)
+}
+
+// validatesResponseBody checks if a response with the status code and content type has a JSON schema to validate its
+// body against.
+func (v *requestValidator) validatesResponseBody(statusCode int, contentType string) bool {
+	if v.operation == nil || !openapi.IsJsonMediaType(contentType) {
+		return false
+	}
+	key, found := serialization.FindResponseKey(v.operation.Responses, statusCode)
+	if !found {
+		return false
+	}
+	for mediaType, content := range v.operation.Responses[key].Content {
+		if content.Schema != nil && openapi.MediaTypeMatches(contentType, mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkBody validates a body against the schema of its media type. Only JSON bodies are validated.
+func (v *requestValidator) checkBody(content map[string]openapi.MediaType, contentType string, data []byte, response bool) {
+	mediaType := v.matchMediaType(content, contentType, response)
+	if mediaType == nil || mediaType.Schema == nil || !openapi.IsJsonMediaType(contentType) {
+		return
+	}
+
+	json, err := JsonUnmarshal(data)
+	if err != nil {
+		v.violation(ViolationFormat, response, err.Error())
+		return
+	}
+
+	reason := ViolationSchema
+	if !response {
+		reason = ViolationRequestBody
+	}
+	messages := make([]string, 0)
+	if !CheckSchema(*mediaType.Schema, json, "body", &messages) {
+		v.violation(reason, response, strings.Join(messages, ", "))
+	}
+}
+
+// matchMediaType returns the media type of the content matching the content type. If none matches, a violation is
+// added and nil is returned.
+func (v *requestValidator) matchMediaType(content map[string]openapi.MediaType, contentType string, response bool) *openapi.MediaType {
+	mediaTypes := make([]string, 0, len(content))
+	for mediaType := range content {
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	sort.Strings(mediaTypes)
+
+	var mediaType *openapi.MediaType
+	for _, key := range mediaTypes {
+		if openapi.MediaTypeMatches(contentType, key) {
+			m := content[key]
+			mediaType = &m
+			break
+		}
+	}
+	if mediaType == nil {
+		v.violation(ViolationContentType, response, fmt.Sprintf("got %s not %s", contentType, strings.Join(mediaTypes, " or ")))
+	}
+	return mediaType
+}
+
+// maxRecordedBody is the maximum size of a response body which is copied to validate it outside of strict mode. Larger
+// bodies are not validated.
+const maxRecordedBody = 10 << 20
+
+// sniffLength is the number of bytes net/http uses to detect the content type of a body.
+const sniffLength = 512
+
+// responseRecorder keeps a copy of the response of the handler. If buffer is true, the response is only sent by flush.
+// Otherwise, the body is only copied if recordBody returns true for the status code and content type of the response.
+type responseRecorder struct {
+	http.ResponseWriter
+	buffer      bool
+	recordBody  func(statusCode int, contentType string) bool
+	record      bool
+	statusCode  int
+	wroteHeader bool
+	hijacked    bool
+	body        bytes.Buffer
+	// sniffed is the start of the body, from which the content type is detected, and size the length of the body
+	sniffed []byte
+	size    int
+}
+
+// Flush sends the data written so far, unless the response is buffered.
+func (r *responseRecorder) Flush() {
+	if r.buffer {
+		return
+	}
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack lets the handler take over the connection, if the wrapped ResponseWriter supports it.
+func (r *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("the ResponseWriter does not support hijacking")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		r.hijacked = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the wrapped ResponseWriter, which http.ResponseController uses.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if r.wroteHeader {
+		return
+	}
+	r.wroteHeader = true
+	r.statusCode = statusCode
+	r.record = r.buffer || r.recordBody(statusCode, r.Header().Get("Content-Type"))
+	if !r.buffer {
+		r.ResponseWriter.WriteHeader(statusCode)
+	}
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	if len(r.sniffed) < sniffLength {
+		end := sniffLength - len(r.sniffed)
+		if end > len(data) {
+			end = len(data)
+		}
+		r.sniffed = append(r.sniffed, data[:end]...)
+	}
+	r.size += len(data)
+	if r.record && !r.buffer && r.size > maxRecordedBody {
+		r.record = false
+		r.body = bytes.Buffer{}
+	}
+	if r.record {
+		r.body.Write(data)
+	}
+	if r.buffer {
+		return len(data), nil
+	}
+	return r.ResponseWriter.Write(data)
+}
+
+// contentType returns the content type of the response, detecting it like net/http does if the handler didn't set it.
+func (r *responseRecorder) contentType() string {
+	if contentType := r.Header().Get("Content-Type"); contentType != "" {
+		return contentType
+	}
+	return http.DetectContentType(r.sniffed)
+}
+
+func (r *responseRecorder) flush() {
+	r.ResponseWriter.WriteHeader(r.statusCode)
+	r.ResponseWriter.Write(r.body.Bytes())
+}
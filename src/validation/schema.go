@@ -0,0 +1,181 @@
+package validation
+
+import (
+	"contract-testing/src/serialization/openapi"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// CheckSchema validates object, a value returned by JsonUnmarshal, against schema. A message is added for every
+// violation, canonicalName is used as name of the object in the messages.
+func CheckSchema(schema openapi.Schema, object interface{}, canonicalName string, messages *[]string) bool {
+	return checkSchema(schema, object, canonicalName, messages, nil)
+}
+
+// checkSchema validates object against schema. combinators contains all subschemas of anyOf, oneOf and allOf that are
+// already being checked against the same object. Entering one of them again would recurse endlessly in a recursive
+// schema, so it is treated as not matching.
+func checkSchema(
+	schema openapi.Schema,
+	object interface{},
+	canonicalName string,
+	messages *[]string,
+	combinators map[*openapi.Schema]bool,
+) bool {
+	typeValid := false
+	childrenValid := true
+	detectedType := "unknown"
+
+	if len(schema.AnyOf) > 0 {
+		for _, subschema := range schema.AnyOf {
+			msgs := make([]string, 0)
+			if checkSubschema(subschema, object, canonicalName, &msgs, combinators) {
+				return true
+			}
+		}
+		*messages = append(*messages, canonicalName+" matches 0 subschemas not any")
+		return false
+	} else if len(schema.OneOf) > 0 {
+		matches := 0
+		for _, subschema := range schema.OneOf {
+			msgs := make([]string, 0)
+			if checkSubschema(subschema, object, canonicalName, &msgs, combinators) {
+				matches += 1
+			}
+		}
+		if matches == 1 {
+			return true
+		}
+		*messages = append(*messages, fmt.Sprintf("%s matches %d subschemas not one", canonicalName, matches))
+		return false
+	} else if len(schema.AllOf) > 0 {
+		matches := 0
+		for _, subschema := range schema.AllOf {
+			msgs := make([]string, 0)
+			if checkSubschema(subschema, object, canonicalName, &msgs, combinators) {
+				matches += 1
+			}
+		}
+		if matches == len(schema.AllOf) {
+			return true
+		}
+		*messages = append(*messages, fmt.Sprintf("%s matches %d subschemas not all", canonicalName, matches))
+		return false
+	}
+
+	switch obj := object.(type) {
+	case bool:
+		detectedType = string(openapi.SchemaTypeBoolean)
+		typeValid = schema.Type == openapi.SchemaTypeBoolean
+	case int64:
+		detectedType = string(openapi.SchemaTypeInteger)
+		typeValid = schema.Type == openapi.SchemaTypeInteger || schema.Type == openapi.SchemaTypeNumber
+	case float32:
+	case float64:
+		detectedType = string(openapi.SchemaTypeNumber)
+		typeValid = schema.Type == openapi.SchemaTypeNumber
+	case string:
+		detectedType = string(openapi.SchemaTypeString)
+		typeValid = schema.Type == openapi.SchemaTypeString
+
+		if schema.Format == openapi.SchemaFormatUri {
+			val, err := url.Parse(obj)
+			if err != nil || val.Host == "" || val.Scheme == "" {
+				*messages = append(*messages, fmt.Sprintf("%s doesn't have format %s", canonicalName, "uri"))
+			}
+		}
+	case []interface{}:
+		detectedType = string(openapi.SchemaTypeArray)
+		typeValid = schema.Type == openapi.SchemaTypeArray
+		if typeValid && schema.Items != nil {
+			for i, val := range obj {
+				check := checkSchema(*schema.Items, val, fmt.Sprintf("%s[%d]", canonicalName, i), messages, nil)
+				childrenValid = check && childrenValid
+			}
+		}
+	case map[string]interface{}:
+		detectedType = string(openapi.SchemaTypeObject)
+		typeValid = schema.Type == openapi.SchemaTypeObject
+
+		for name, property := range schema.Properties {
+			if val, ok := obj[name]; ok {
+				check := checkSchema(*property, val, canonicalName+"."+name, messages, nil)
+				childrenValid = check && childrenValid
+			} else if schema.Requires(name) {
+				childrenValid = false
+				*messages = append(*messages, "missing property "+canonicalName+"."+name)
+			}
+		}
+
+		if schema.AdditionalProperties != nil {
+			for name, val := range obj {
+				if _, ok := schema.Properties[name]; ok {
+					continue
+				}
+				if !schema.AdditionalProperties.Allowed {
+					childrenValid = false
+					*messages = append(*messages, "unexpected property "+canonicalName+"."+name)
+				} else if schema.AdditionalProperties.Schema != nil {
+					check := checkSchema(*schema.AdditionalProperties.Schema, val, canonicalName+"."+name, messages, nil)
+					childrenValid = check && childrenValid
+				}
+			}
+		}
+	case nil:
+		detectedType = "null"
+		typeValid = schema.Nullable
+	}
+	if !typeValid {
+		*messages = append(*messages, fmt.Sprintf("%s is %s not %s", canonicalName, detectedType, schema.Type))
+	}
+	return typeValid && childrenValid
+}
+
+// checkSubschema checks object against a subschema of a combinator (anyOf, oneOf, allOf) on the same object.
+func checkSubschema(
+	subschema *openapi.Schema,
+	object interface{},
+	canonicalName string,
+	messages *[]string,
+	combinators map[*openapi.Schema]bool,
+) bool {
+	if combinators[subschema] {
+		return false
+	}
+
+	entered := make(map[*openapi.Schema]bool, len(combinators)+1)
+	for s := range combinators {
+		entered[s] = true
+	}
+	entered[subschema] = true
+
+	return checkSchema(*subschema, object, canonicalName, messages, entered)
+}
+
+// ParseParameterValue converts the string value of a parameter to the type described by schema. Arrays are expected
+// as comma separated values. Values that can't be converted are returned as string.
+func ParseParameterValue(value string, schema openapi.Schema) interface{} {
+	switch schema.Type {
+	case openapi.SchemaTypeString:
+		return value
+	case openapi.SchemaTypeArray:
+		items := make([]interface{}, 0)
+		if value == "" {
+			return items
+		}
+		itemSchema := openapi.Schema{}
+		if schema.Items != nil {
+			itemSchema = *schema.Items
+		}
+		for _, item := range strings.Split(value, ",") {
+			items = append(items, ParseParameterValue(item, itemSchema))
+		}
+		return items
+	}
+
+	if parsed, err := JsonUnmarshal([]byte(value)); err == nil {
+		return parsed
+	}
+	return value
+}